@@ -0,0 +1,232 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Объект управления ранее запущенным процессом.
+type attached struct {
+	pid        int         // Идентификатор процесса.
+	startTicks uint64      // Время запуска процесса после загрузки системы, в тиках.
+	pidfd      int         // Файловый дескриптор процесса, или -1, если pidfd не поддерживается.
+	pidfdSync  *sync.Mutex // Контроль монопольного доступа к pidfd.
+}
+
+// Attach Подключение к ранее запущенному процессу по его PID.
+// Процесс не обязан быть дочерним процессом текущего приложения.
+func Attach(pid int) (ret Attached, err error) {
+	const (
+		errPid     = "не верный PID процесса: %d"
+		errProcess = "процесс %d не найден"
+	)
+	var (
+		att   *attached
+		ticks uint64
+		alive bool
+	)
+
+	if pid <= 0 {
+		err = fmt.Errorf(errPid, pid)
+		return
+	}
+	if ticks, alive, err = procStartTicks(pid); err != nil {
+		return
+	} else if !alive {
+		err = fmt.Errorf(errProcess, pid)
+		return
+	}
+	att = &attached{pid: pid, startTicks: ticks, pidfd: -1, pidfdSync: new(sync.Mutex)}
+	if fd, e := pidfdOpen(pid); e == nil {
+		att.pidfd = fd
+	}
+	// Проверка, что между чтением времени запуска и открытием pidfd процесс не был заменён.
+	if err = att.verify(); err != nil {
+		_ = att.Release()
+		return
+	}
+	ret = att
+
+	return
+}
+
+// Проверка того, что процесс выполняется и является тем же процессом, к которому выполнялось подключение.
+func (att *attached) verify() (err error) {
+	const (
+		errExited = "процесс %d завершён"
+		errReused = "PID %d принадлежит другому процессу, исходный процесс завершён"
+	)
+	var (
+		ticks uint64
+		alive bool
+	)
+
+	if ticks, alive, err = procStartTicks(att.pid); err != nil {
+		return
+	}
+	switch {
+	case !alive:
+		err = fmt.Errorf(errExited, att.pid)
+	case ticks != att.startTicks:
+		err = fmt.Errorf(errReused, att.pid)
+	}
+
+	return
+}
+
+// Pid Возвращает PID процесса.
+func (att *attached) Pid() int { return att.pid }
+
+// Info Информация о процессе: командная строка, рабочая директория, переменные окружения и время запуска.
+func (att *attached) Info() (ret *ProcessInfo, err error) {
+	const errReused = "PID %d принадлежит другому процессу, исходный процесс завершён"
+
+	if ret, err = procInfo(att.pid); err != nil {
+		return
+	}
+	if ret.startTicks != att.startTicks {
+		ret, err = nil, fmt.Errorf(errReused, att.pid)
+	}
+
+	return
+}
+
+// Alive Возвращает истину, если процесс выполняется и является тем же процессом.
+func (att *attached) Alive() bool { return att.verify() == nil }
+
+// Signal Отправка сигнала процессу.
+func (att *attached) Signal(sig os.Signal) (err error) {
+	const errSignal = "сигнал %q не поддерживается"
+	var (
+		s  syscall.Signal
+		ok bool
+	)
+
+	if s, ok = sig.(syscall.Signal); !ok {
+		return fmt.Errorf(errSignal, sig)
+	}
+	att.pidfdSync.Lock()
+	defer att.pidfdSync.Unlock()
+	if att.pidfd >= 0 {
+		if err = pidfdSendSignal(att.pidfd, s); !errors.Is(err, syscall.ENOSYS) {
+			return
+		}
+	}
+	if err = att.verify(); err != nil {
+		return
+	}
+	err = syscall.Kill(att.pid, s)
+
+	return
+}
+
+// Kill Принудительное завершение процесса сигналом SIGKILL.
+func (att *attached) Kill() error { return att.Signal(syscall.SIGKILL) }
+
+// Stop Завершение процесса в соответствии с политикой завершения.
+// Если политика не указана, используется политика по умолчанию DefaultStopPolicy().
+func (att *attached) Stop(ctx context.Context, policy *StopPolicy) (err error) {
+	var (
+		waitContext context.Context
+		waitCancel  context.CancelFunc
+	)
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if policy == nil {
+		policy = DefaultStopPolicy()
+	}
+	if policy.Signal == nil {
+		policy = &StopPolicy{Signal: syscall.SIGTERM, Timeout: policy.Timeout, Kill: policy.Kill}
+	}
+	if err = att.Signal(policy.Signal); err != nil {
+		if !att.Alive() {
+			err = nil
+		}
+		return
+	}
+	waitContext, waitCancel = context.WithTimeout(ctx, policy.Timeout)
+	err = att.Wait(waitContext)
+	waitCancel()
+	if err == nil || ctx.Err() != nil || !policy.Kill {
+		return
+	}
+	if err = att.Kill(); err != nil {
+		if !att.Alive() {
+			err = nil
+		}
+		return
+	}
+	err = att.Wait(ctx)
+
+	return
+}
+
+// Wait Ожидание завершения процесса. Ожидание выполняется через pidfd, а если ядро не поддерживает
+// pidfd, тогда через периодическую проверку файловой системы /proc. Вызов Release() во время ожидания
+// не прерывает его.
+func (att *attached) Wait(ctx context.Context) (err error) {
+	var (
+		fd   = -1
+		done bool
+	)
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	// Номер закрытого в Release() дескриптора может быть занят другим файлом, поэтому ожидание не использует
+	// дескриптор объекта после освобождения блокировки. Если копию создать не удалось, используется /proc.
+	att.pidfdSync.Lock()
+	if att.pidfd >= 0 {
+		fd, _ = pidfdDup(att.pidfd)
+	}
+	att.pidfdSync.Unlock()
+	defer func() {
+		if fd >= 0 {
+			_ = pidfdClose(fd)
+		}
+	}()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		if fd >= 0 {
+			if done, err = pidfdWait(fd, procPollPeriod); err == nil {
+				if done {
+					return
+				}
+				continue
+			}
+			// Ошибка ожидания через pidfd, переход к проверке через /proc.
+			_ = pidfdClose(fd)
+			fd, err = -1, nil
+		}
+		if !att.Alive() {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(procPollPeriod):
+		}
+	}
+}
+
+// Release Освобождение ресурсов, связанных с процессом. Процесс продолжает выполняться.
+func (att *attached) Release() (err error) {
+	att.pidfdSync.Lock()
+	defer att.pidfdSync.Unlock()
+	if att.pidfd >= 0 {
+		err, att.pidfd = pidfdClose(att.pidfd), -1
+	}
+
+	return
+}
@@ -0,0 +1,64 @@
+package run
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"time"
+)
+
+// Attached Интерфейс управления ранее запущенным процессом, не являющимся дочерним процессом пакета.
+// Все операции проверяют, что процесс с указанным PID является тем же процессом, к которому выполнялось
+// подключение, сравнивая время запуска процесса, что исключает воздействие на процесс с переиспользованным PID.
+type Attached interface {
+	// Pid Возвращает PID процесса.
+	Pid() int
+
+	// Info Информация о процессе: командная строка, рабочая директория, переменные окружения и время запуска.
+	Info() (ret *ProcessInfo, err error)
+
+	// Alive Возвращает истину, если процесс выполняется и является тем же процессом.
+	Alive() bool
+
+	// Signal Отправка сигнала процессу.
+	Signal(sig os.Signal) error
+
+	// Kill Принудительное завершение процесса сигналом SIGKILL.
+	Kill() error
+
+	// Stop Завершение процесса в соответствии с политикой завершения.
+	// Если политика не указана, используется политика по умолчанию DefaultStopPolicy().
+	Stop(ctx context.Context, policy *StopPolicy) error
+
+	// Wait Ожидание завершения процесса. Ожидание выполняется через pidfd, а если ядро не поддерживает
+	// pidfd, тогда через периодическую проверку файловой системы /proc. Вызов Release() во время ожидания
+	// не прерывает его.
+	Wait(ctx context.Context) error
+
+	// Release Освобождение ресурсов, связанных с процессом. Процесс продолжает выполняться.
+	Release() error
+}
+
+// ProcessInfo Информация о процессе.
+type ProcessInfo struct {
+	Pid        int       // Идентификатор процесса.
+	PPid       int       // Идентификатор родительского процесса.
+	Name       string    // Имя исполняемого файла процесса.
+	Cmdline    []string  // Командная строка процесса.
+	Cwd        string    // Рабочая директория процесса.
+	Env        []string  // Переменные окружения процесса.
+	StartTime  time.Time // Время запуска процесса.
+	startTicks uint64    // Время запуска процесса после загрузки системы, в тиках.
+}
+
+// StopPolicy Политика завершения процесса.
+type StopPolicy struct {
+	Signal  os.Signal     // Сигнал мягкого завершения процесса. По умолчанию SIGTERM.
+	Timeout time.Duration // Время ожидания завершения процесса после отправки сигнала.
+	Kill    bool          // Принудительное завершение процесса сигналом SIGKILL по истечении времени ожидания.
+}
+
+// DefaultStopPolicy Политика завершения процесса по умолчанию: сигнал SIGTERM, ожидание 4 секунды, SIGKILL.
+func DefaultStopPolicy() *StopPolicy {
+	return &StopPolicy{Signal: syscall.SIGTERM, Timeout: 4 * time.Second, Kill: true}
+}
@@ -0,0 +1,109 @@
+//go:build linux
+
+package run
+
+import (
+	"context"
+	"os/exec"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// Запуск процесса sleep, не являющегося процессом пакета. Процесс завершается и освобождается после теста.
+func testSleep(t *testing.T) (ret *exec.Cmd, exited <-chan struct{}) {
+	var done = make(chan struct{})
+
+	ret = exec.Command("sleep", "30")
+	if err := ret.Start(); err != nil {
+		t.Fatalf("запуск процесса sleep прерван ошибкой: %v", err)
+	}
+	go func() { _ = ret.Wait(); close(done) }()
+	t.Cleanup(func() { _ = ret.Process.Kill(); <-done })
+
+	return ret, done
+}
+
+func TestAttach(t *testing.T) {
+	var cmd, _ = testSleep(t)
+
+	att, err := Attach(cmd.Process.Pid)
+	if err != nil {
+		t.Fatalf("ошибка подключения к процессу: %v", err)
+	}
+	defer func() { _ = att.Release() }()
+	if att.Pid() != cmd.Process.Pid || !att.Alive() {
+		t.Errorf("Pid() = %d, Alive() = %t, ожидается %d и истина", att.Pid(), att.Alive(), cmd.Process.Pid)
+	}
+	info, err := att.Info()
+	if err != nil {
+		t.Fatalf("ошибка получения информации о процессе: %v", err)
+	}
+	if info.Pid != cmd.Process.Pid || info.Name != "sleep" || len(info.Cmdline) != 2 || info.Cmdline[1] != "30" {
+		t.Errorf("неверная информация о процессе: %+v", info)
+	}
+	if _, err = Attach(-1); err == nil {
+		t.Errorf("подключение к PID -1 выполнено без ошибки")
+	}
+}
+
+// Процесс с тем же PID, но другим временем запуска, считается другим процессом, и сигнал ему не отправляется.
+func TestAttachReused(t *testing.T) {
+	var cmd, exited = testSleep(t)
+
+	ticks, alive, err := procStartTicks(cmd.Process.Pid)
+	if err != nil || !alive {
+		t.Fatalf("ошибка чтения времени запуска процесса: %v", err)
+	}
+	att := &attached{pid: cmd.Process.Pid, startTicks: ticks + 1, pidfd: -1, pidfdSync: new(sync.Mutex)}
+	if att.Alive() {
+		t.Errorf("процесс с другим временем запуска считается тем же процессом")
+	}
+	if _, err = att.Info(); err == nil {
+		t.Errorf("Info() процесса с другим временем запуска выполнена без ошибки")
+	}
+	if err = att.Signal(syscall.SIGTERM); err == nil {
+		t.Errorf("Signal() процессу с другим временем запуска выполнена без ошибки")
+	}
+	select {
+	case <-exited:
+		t.Errorf("сигнал доставлен процессу с другим временем запуска")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// Ожидание завершения процесса возвращает управление после отправки сигнала завершения, в том числе если
+// во время ожидания вызвана функция Release().
+func TestAttachWaitSignal(t *testing.T) {
+	for _, release := range []bool{false, true} {
+		var (
+			cmd, _ = testSleep(t)
+			done   = make(chan error, 1)
+		)
+
+		att, err := Attach(cmd.Process.Pid)
+		if err != nil {
+			t.Fatalf("ошибка подключения к процессу: %v", err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		go func() { done <- att.Wait(ctx) }()
+		time.Sleep(50 * time.Millisecond)
+		if release {
+			if err = att.Release(); err != nil {
+				t.Errorf("ошибка освобождения ресурсов: %v", err)
+			}
+		}
+		if err = att.Signal(syscall.SIGTERM); err != nil {
+			t.Errorf("ошибка отправки сигнала: %v", err)
+		}
+		if err = <-done; err != nil {
+			t.Errorf("Release() = %t: ожидание завершения процесса прервано ошибкой: %v", release, err)
+		}
+		if att.Alive() {
+			t.Errorf("процесс выполняется после завершения ожидания")
+		}
+		cancel()
+		_ = att.Release()
+	}
+}
@@ -10,10 +10,12 @@ import (
 
 // WorkingDirectory Назначение директории выполнения приложения. По умолчанию - текущая директория.
 func (run *impl) WorkingDirectory(dir string) Interface {
-	const msgDir = "директория выполнения процесса: %q"
+	const msgDir = "config.dir"
 
+	run.fieldSync.Lock()
 	run.attributes.Dir = dir
-	run.debug(msgDir, run.attributes.Dir)
+	run.fieldSync.Unlock()
+	run.log(LevelDebug, msgDir, attr("dir", dir))
 
 	return run
 }
@@ -21,24 +23,28 @@ func (run *impl) WorkingDirectory(dir string) Interface {
 // Environment Переменные окружения, устанавливаемые для приложения.
 // Переменные указываются как "КЛЮЧ=Значение".
 func (run *impl) Environment(env ...string) Interface {
-	const msgEnv = "переменные окружения: %v"
+	const msgEnv = "config.env"
 
+	run.fieldSync.Lock()
 	run.attributes.Env = make([]string, 0, len(env))
 	run.attributes.Env = append(run.attributes.Env, env...)
-	run.debug(msgEnv, run.attributes.Env)
+	run.fieldSync.Unlock()
+	run.log(LevelDebug, msgEnv, attr("env", env))
 
 	return run
 }
 
 // Chroot Запускаемое приложение выполняется в режиме chroot в указанной директории.
 func (run *impl) Chroot(dir string) Interface {
-	const msgChroot = "директория chroot выполнения процесса: %q"
+	const msgChroot = "config.chroot"
 
+	run.fieldSync.Lock()
 	if run.attributes.Sys == nil {
 		run.attributes.Sys = new(syscall.SysProcAttr)
 	}
 	run.attributes.Sys.Chroot = dir
-	run.debug(msgChroot, run.attributes.Sys.Chroot)
+	run.fieldSync.Unlock()
+	run.log(LevelDebug, msgChroot, attr("dir", dir))
 
 	return run
 }
@@ -49,8 +55,9 @@ func (run *impl) Chroot(dir string) Interface {
 // noSetGroups - Флаг, указывающий не устанавливать дополнительные группы.
 // groups      - Массив идентификаторов дополнительных групп.
 func (run *impl) Sudo(userID uint32, groupID uint32, noSetGroups bool, groups ...uint32) Interface {
-	const msgSudo = "выполнение процесса от пользователя %d и группы %d"
+	const msgSudo = "config.credential"
 
+	run.fieldSync.Lock()
 	if run.attributes.Sys == nil {
 		run.attributes.Sys = new(syscall.SysProcAttr)
 	}
@@ -61,7 +68,8 @@ func (run *impl) Sudo(userID uint32, groupID uint32, noSetGroups bool, groups ..
 	run.attributes.Sys.Credential.NoSetGroups = noSetGroups
 	run.attributes.Sys.Credential.Groups = make([]uint32, 0, len(groups))
 	run.attributes.Sys.Credential.Groups = append(run.attributes.Sys.Credential.Groups, groups...)
-	run.debug(msgSudo, run.attributes.Sys.Credential.Uid, run.attributes.Sys.Credential.Gid)
+	run.fieldSync.Unlock()
+	run.log(LevelDebug, msgSudo, attr("uid", userID), attr("gid", groupID), attr("groups", groups))
 
 	return run
 }
@@ -127,4 +135,52 @@ func (run *impl) GroupID(groupName string) (ret uint32, err error) {
 }
 
 // Command Функция возвращает текущую запущенную команду.
-func (run *impl) Command() (ret []string) { return run.cmd }
+func (run *impl) Command() (ret []string) {
+	run.fieldSync.RLock()
+	ret = append(make([]string, 0, len(run.cmd)), run.cmd...)
+	run.fieldSync.RUnlock()
+
+	return
+}
+
+// BufferSize Размер буфера чтения и записи потоков в байтах, по умолчанию 32 KiB.
+// Значение равное или меньше нуля устанавливает размер по умолчанию. Размер применяется при следующем запуске.
+func (run *impl) BufferSize(size int) Interface {
+	const msgBufferSize = "config.buffer.size"
+
+	if size <= 0 {
+		size = bufLength
+	}
+	run.fieldSync.Lock()
+	run.bufLen = size
+	run.fieldSync.Unlock()
+	run.log(LevelDebug, msgBufferSize, attr("size", size))
+
+	return run
+}
+
+// ChannelSize Размер буфера каналов STDIN, подписчиков, событий и статистики, по умолчанию 64.
+// Значение меньше нуля устанавливает размер по умолчанию, значение ноль создаёт каналы без буфера.
+// Размер применяется к каналам, создаваемым после вызова функции.
+func (run *impl) ChannelSize(size int) Interface {
+	const msgChannelSize = "config.channel.size"
+
+	if size < 0 {
+		size = chanLength
+	}
+	run.fieldSync.Lock()
+	run.chanLen = size
+	run.fieldSync.Unlock()
+	run.log(LevelDebug, msgChannelSize, attr("size", size))
+
+	return run
+}
+
+// Размер буфера чтения и записи потоков и размер буфера каналов.
+func (run *impl) sizeGet() (bufLen int, chanLen int) {
+	run.fieldSync.RLock()
+	bufLen, chanLen = run.bufLen, run.chanLen
+	run.fieldSync.RUnlock()
+
+	return
+}
@@ -0,0 +1,215 @@
+package run
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+const (
+	docNull   = iota // Значение null.
+	docBool          // Логическое значение.
+	docNumber        // Число.
+	docString        // Строка.
+	docList          // Список.
+	docMap           // Объект.
+)
+
+// Узел дерева документа, общего для форматов JSON и YAML.
+// Порядок полей объекта сохраняется, что позволяет формировать документы с постоянным порядком полей.
+type docNode struct {
+	kind  int        // Тип узла.
+	value string     // Текст скалярного значения.
+	keys  []string   // Имена полей объекта.
+	items []*docNode // Значения полей объекта или элементы списка.
+	line  int        // Номер строки документа YAML, 0 - не известен.
+}
+
+// SpecError Ошибка разбора или проверки описания запуска с указанием пути к полю,
+// например "stdout.perm" или "[1].args[0]" для второго описания в списке.
+type SpecError struct {
+	Path string // Путь к полю, пустое значение - документ целиком.
+	Line int    // Номер строки документа YAML, 0 - не известен.
+	Err  error  // Ошибка.
+}
+
+// Error Реализация интерфейса error.
+func (e *SpecError) Error() (ret string) {
+	ret = e.Err.Error()
+	if e.Path != "" {
+		ret = e.Path + ": " + ret
+	}
+	if e.Line > 0 {
+		ret = "строка " + strconv.Itoa(e.Line) + ": " + ret
+	}
+
+	return
+}
+
+// Unwrap Исходная ошибка.
+func (e *SpecError) Unwrap() error { return e.Err }
+
+// Путь к полю объекта.
+func docPathKey(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// Путь к элементу списка.
+func docPathIndex(path string, n int) string { return path + "[" + strconv.Itoa(n) + "]" }
+
+// Название типа узла для сообщений об ошибках.
+func docKindName(kind int) string {
+	switch kind {
+	case docBool:
+		return "логическое значение"
+	case docNumber:
+		return "число"
+	case docString:
+		return "строка"
+	case docList:
+		return "список"
+	case docMap:
+		return "объект"
+	default:
+		return "null"
+	}
+}
+
+// Конструкторы узлов.
+func docStr(s string) *docNode              { return &docNode{kind: docString, value: s} }
+func docInt(n int64) *docNode               { return &docNode{kind: docNumber, value: strconv.FormatInt(n, 10)} }
+func docBoolean(b bool) *docNode            { return &docNode{kind: docBool, value: strconv.FormatBool(b)} }
+func docObjectNew() *docNode                { return &docNode{kind: docMap} }
+func docListNew(items ...*docNode) *docNode { return &docNode{kind: docList, items: items} }
+
+// Добавление поля объекта.
+func (n *docNode) set(key string, value *docNode) *docNode {
+	n.keys, n.items = append(n.keys, key), append(n.items, value)
+	return n
+}
+
+// Разбор документа JSON в дерево документа.
+func docParseJSON(data []byte) (ret *docNode, err error) {
+	const errTrailing = "лишние данные после окончания документа"
+	var dec = json.NewDecoder(bytes.NewReader(data))
+
+	dec.UseNumber()
+	if ret, err = docReadJSON(dec, ""); err != nil {
+		return
+	}
+	if _, e := dec.Token(); !errors.Is(e, io.EOF) {
+		ret, err = nil, &SpecError{Err: errors.New(errTrailing)}
+	}
+
+	return
+}
+
+// Чтение значения JSON.
+func docReadJSON(dec *json.Decoder, path string) (ret *docNode, err error) {
+	const errDuplicate = "повторяющееся поле %q"
+	var (
+		tok   json.Token
+		key   string
+		value *docNode
+	)
+
+	if tok, err = dec.Token(); err != nil {
+		err = &SpecError{Path: path, Err: err}
+		return
+	}
+	switch t := tok.(type) {
+	case nil:
+		ret = &docNode{kind: docNull}
+	case bool:
+		ret = docBoolean(t)
+	case json.Number:
+		ret = &docNode{kind: docNumber, value: t.String()}
+	case string:
+		ret = docStr(t)
+	case json.Delim:
+		if t == '[' {
+			ret = docListNew()
+			for dec.More() {
+				if value, err = docReadJSON(dec, docPathIndex(path, len(ret.items))); err != nil {
+					return
+				}
+				ret.items = append(ret.items, value)
+			}
+		} else {
+			ret = docObjectNew()
+			for dec.More() {
+				if tok, err = dec.Token(); err != nil {
+					err = &SpecError{Path: path, Err: err}
+					return
+				}
+				key = tok.(string)
+				if ret.field(key) != nil {
+					err = &SpecError{Path: path, Err: fmt.Errorf(errDuplicate, key)}
+					return
+				}
+				if value, err = docReadJSON(dec, docPathKey(path, key)); err != nil {
+					return
+				}
+				ret.set(key, value)
+			}
+		}
+		// Закрывающая скобка.
+		if _, err = dec.Token(); err != nil {
+			err = &SpecError{Path: path, Err: err}
+		}
+	}
+
+	return
+}
+
+// Значение поля объекта по имени, nil - поле отсутствует.
+func (n *docNode) field(key string) *docNode {
+	for i := range n.keys {
+		if n.keys[i] == key {
+			return n.items[i]
+		}
+	}
+	return nil
+}
+
+// Запись дерева документа в формате JSON без отступов.
+func (n *docNode) writeJSON(buf *bytes.Buffer) {
+	var data []byte
+
+	switch n.kind {
+	case docNull:
+		buf.WriteString("null")
+	case docBool, docNumber:
+		buf.WriteString(n.value)
+	case docString:
+		data, _ = json.Marshal(n.value)
+		buf.Write(data)
+	case docList:
+		buf.WriteByte('[')
+		for i := range n.items {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			n.items[i].writeJSON(buf)
+		}
+		buf.WriteByte(']')
+	case docMap:
+		buf.WriteByte('{')
+		for i := range n.keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			data, _ = json.Marshal(n.keys[i])
+			buf.Write(data)
+			buf.WriteByte(':')
+			n.items[i].writeJSON(buf)
+		}
+		buf.WriteByte('}')
+	}
+}
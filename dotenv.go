@@ -0,0 +1,230 @@
+package run
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	errDotenvRead   = "чтение файла переменных окружения %q прервано ошибкой: %s"
+	errDotenvSyntax = "файл переменных окружения %q, строка %d: %s"
+	errDotenvKey    = "недопустимое имя переменной %q"
+	errDotenvAssign = "ожидается \"КЛЮЧ=Значение\""
+	errDotenvQuote  = "не закрыта кавычка"
+	errDotenvTail   = "лишние символы после закрывающей кавычки"
+)
+
+// Переменная окружения файла .env. Значение записано в виде, принимаемом envTable.expand(): подстановки
+// переменных записаны как ${КЛЮЧ}, а символы "$", не являющиеся подстановкой, записаны как "$$".
+type dotenvEntry struct {
+	key   string // Имя переменной.
+	value string // Значение переменной.
+}
+
+// EnvironmentFile Загрузка переменных окружения процесса из файлов .env, файлы читаются при вызове функции.
+// Поддерживаются комментарии, префикс "export", значения без кавычек, в одинарных кавычках без подстановок и
+// экранирования, в двойных кавычках с экранированием \n, \t, \r, \", \\, \$, многострочные значения в кавычках
+// и подстановки $КЛЮЧ, ${КЛЮЧ} и ${КЛЮЧ:-по умолчанию} из ранее прочитанных переменных и базового окружения.
+// Приоритет переменных: окружение текущего процесса < файлы .env в порядке загрузки < Environment() <
+// изменения Setenv(), Unsetenv(), EnvironmentMap() и фильтры. Ошибка чтения доступна через Error().
+func (run *impl) EnvironmentFile(paths ...string) Interface {
+	const msgFile = "config.env.file"
+	var (
+		err     error
+		data    []byte
+		entries []dotenvEntry
+		loaded  []dotenvEntry
+	)
+
+	for _, path := range paths {
+		if data, err = os.ReadFile(path); err != nil {
+			run.errSet(fmt.Errorf(errDotenvRead, path, err))
+			return run
+		}
+		if entries, err = dotenvParse(path, string(data)); err != nil {
+			run.errSet(err)
+			return run
+		}
+		loaded = append(loaded, entries...)
+	}
+	run.fieldSync.Lock()
+	run.envFiles = append(run.envFiles, loaded...)
+	run.fieldSync.Unlock()
+	run.log(LevelDebug, msgFile, attr("paths", paths), attr("count", len(loaded)))
+
+	return run
+}
+
+// Разбор содержимого файла .env.
+func dotenvParse(path string, data string) (ret []dotenvEntry, err error) {
+	var (
+		line, next int
+		text       string
+		key, value string
+		ok         bool
+	)
+
+	data = strings.ReplaceAll(data, "\r\n", "\n")
+	for line = 1; data != ""; line = next {
+		next = line + 1
+		if text, data, _ = strings.Cut(data, "\n"); strings.TrimSpace(text) == "" {
+			continue
+		}
+		if text = strings.TrimLeft(text, " \t"); text[0] == '#' {
+			continue
+		}
+		if rest := strings.TrimPrefix(text, "export"); rest != "" && rest != text && (rest[0] == ' ' || rest[0] == '\t') {
+			text = strings.TrimLeft(rest, " \t")
+		}
+		if key, text, ok = strings.Cut(text, "="); !ok {
+			err = fmt.Errorf(errDotenvSyntax, path, line, errDotenvAssign)
+			return
+		}
+		if key = strings.TrimSpace(key); !dotenvKey(key) {
+			err = fmt.Errorf(errDotenvSyntax, path, line, fmt.Sprintf(errDotenvKey, key))
+			return
+		}
+		// Значение в кавычках может продолжаться на следующих строках.
+		if text = strings.TrimLeft(text, " \t"); text != "" && (text[0] == '"' || text[0] == '\'') {
+			for !dotenvClosed(text) && data != "" {
+				var more string
+				more, data, _ = strings.Cut(data, "\n")
+				text, next = text+"\n"+more, next+1
+			}
+		}
+		if value, err = dotenvValue(text); err != nil {
+			err = fmt.Errorf(errDotenvSyntax, path, line, err)
+			return
+		}
+		ret = append(ret, dotenvEntry{key: key, value: value})
+	}
+
+	return
+}
+
+// Проверка имени переменной.
+func dotenvKey(key string) bool {
+	if key == "" || key[0] >= '0' && key[0] <= '9' {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		switch c := key[i]; {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '_', c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Позиция закрывающей кавычки значения, начинающегося с кавычки, -1 - кавычка не закрыта.
+func dotenvQuoteEnd(text string) int {
+	for i := 1; i < len(text); i++ {
+		switch {
+		case text[0] == '"' && text[i] == '\\':
+			i++
+		case text[i] == text[0]:
+			return i
+		}
+	}
+	return -1
+}
+
+// Признак закрытой кавычки значения.
+func dotenvClosed(text string) bool { return dotenvQuoteEnd(text) >= 0 }
+
+// Значение переменной в виде, принимаемом envTable.expand().
+func dotenvValue(text string) (ret string, err error) {
+	var (
+		end int
+		buf strings.Builder
+	)
+
+	switch {
+	case text == "":
+		return
+	case text[0] == '\'':
+		if end = dotenvQuoteEnd(text); end < 0 {
+			return "", errors.New(errDotenvQuote)
+		}
+		ret = strings.ReplaceAll(text[1:end], "$", "$$")
+	case text[0] == '"':
+		if end = dotenvQuoteEnd(text); end < 0 {
+			return "", errors.New(errDotenvQuote)
+		}
+		for i := 1; i < end; i++ {
+			if text[i] != '\\' {
+				dotenvByte(&buf, text[:end], &i)
+				continue
+			}
+			i++
+			switch text[i] {
+			case 'n':
+				buf.WriteByte('\n')
+			case 't':
+				buf.WriteByte('\t')
+			case 'r':
+				buf.WriteByte('\r')
+			case '$':
+				buf.WriteString("$$")
+			case '"', '\\':
+				buf.WriteByte(text[i])
+			default:
+				buf.WriteByte('\\')
+				buf.WriteByte(text[i])
+			}
+		}
+		ret = buf.String()
+	default:
+		// Комментарий в значении без кавычек начинается с символа "#" после пробела.
+		if end = strings.Index(text, " #"); end >= 0 {
+			text = text[:end]
+		}
+		if end = strings.Index(text, "\t#"); end >= 0 {
+			text = text[:end]
+		}
+		text = strings.TrimSpace(text)
+		for i := 0; i < len(text); i++ {
+			dotenvByte(&buf, text, &i)
+		}
+		return buf.String(), nil
+	}
+	if tail := strings.TrimSpace(text[end+1:]); tail != "" && tail[0] != '#' {
+		return "", errors.New(errDotenvTail)
+	}
+
+	return
+}
+
+// Запись символа значения с приведением подстановки $КЛЮЧ к виду ${КЛЮЧ}.
+// Символ "$", не начинающий подстановку, записывается как "$$".
+func dotenvByte(buf *strings.Builder, text string, i *int) {
+	var end int
+
+	if text[*i] != '$' {
+		buf.WriteByte(text[*i])
+		return
+	}
+	switch rest := text[*i+1:]; {
+	case strings.HasPrefix(rest, "{"):
+		if end = strings.IndexByte(rest, '}'); end < 0 {
+			buf.WriteString("$$")
+			return
+		}
+		buf.WriteString(text[*i : *i+end+2])
+		*i += end + 1
+	default:
+		for end < len(rest) && (rest[end] == '_' || rest[end] >= 'a' && rest[end] <= 'z' ||
+			rest[end] >= 'A' && rest[end] <= 'Z' || end > 0 && rest[end] >= '0' && rest[end] <= '9') {
+			end++
+		}
+		if end == 0 {
+			buf.WriteString("$$")
+			return
+		}
+		buf.WriteString("${" + rest[:end] + "}")
+		*i += end
+	}
+}
@@ -0,0 +1,168 @@
+package run
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDotenvParse(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		out  []string
+	}{
+		{"пустой файл", "", nil},
+		{"комментарии и пустые строки", "# комментарий\n\n  # ещё комментарий\nA=1\n", []string{"A=1"}},
+		{"префикс export", "export A=1\nexport\tB=2\nexportC=3\n", []string{"A=1", "B=2", "exportC=3"}},
+		{"пробелы вокруг знака равенства", "A = 1 \n", []string{"A=1"}},
+		{"перевод строки Windows", "A=1\r\nB=2\r\n", []string{"A=1", "B=2"}},
+		{"пустое значение", "A=\nB=''\nC=\"\"", []string{"A=", "B=", "C="}},
+		{"комментарий после значения", "A=x #комментарий\nB=x\t# комментарий", []string{"A=x", "B=x"}},
+		{"символ # без пробела", "A=x#y", []string{"A=x#y"}},
+		{"комментарий после кавычки", "A='x' # комментарий", []string{"A=x"}},
+		{"подстановки без кавычек", "A=1\nB=$A-${A}-${C:-d}", []string{"A=1", "B=1-1-d"}},
+		{"знак доллара без подстановки", "A=a$ b$", []string{"A=a$ b$"}},
+		{"незакрытая скобка подстановки", "A=${B", []string{"A=${B"}},
+		{"одинарные кавычки без подстановок", "A=1\nB='$A ${A} \\n'", []string{"A=1", "B=$A ${A} \\n"}},
+		{"двойные кавычки с подстановками", "A=1\nB=\"$A ${A:-d} \\$A\"", []string{"A=1", "B=1 1 $A"}},
+		{"экранирование в двойных кавычках", `A="a\nb\tc\rd\"e\\f\xg"`, []string{"A=a\nb\tc\rd\"e\\f\\xg"}},
+		{"двойные кавычки внутри одинарных", `A='"x"'`, []string{`A="x"`}},
+		{"многострочное значение", "A=\"строка 1\nстрока 2\"\nB='x\n\ny'\nC=3", []string{
+			"A=строка 1\nстрока 2", "B=x\n\ny", "C=3",
+		}},
+		{"повторная переменная", "A=1\nA=${A}2", []string{"A=12"}},
+		{"имя с точкой", "a.b_C1=1", []string{"a.b_C1=1"}},
+	}
+	for _, tt := range tests {
+		entries, err := dotenvParse("test.env", tt.in)
+		if err != nil {
+			t.Errorf("%s: ошибка разбора: %v", tt.name, err)
+			continue
+		}
+		// Значения подставляются так же, как при формировании окружения процесса.
+		et := newEnvTable(nil)
+		for _, entry := range entries {
+			et.set(entry.key, et.expand(entry.value))
+		}
+		if out := et.list(); len(out) != len(tt.out) || len(out) > 0 && !reflect.DeepEqual(out, tt.out) {
+			t.Errorf("%s: окружение %q, ожидается %q", tt.name, out, tt.out)
+		}
+	}
+}
+
+func TestDotenvParseError(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		line int
+		err  string
+	}{
+		{"нет знака равенства", "A=1\nB\n", 2, errDotenvAssign},
+		{"имя начинается с цифры", "1A=1", 1, "1A"},
+		{"пустое имя", "=1", 1, `""`},
+		{"недопустимый символ в имени", "A-B=1", 1, "A-B"},
+		{"не закрыта одинарная кавычка", "A='x\nB=2", 1, errDotenvQuote},
+		{"не закрыта двойная кавычка", "A=\"x\\\"", 1, errDotenvQuote},
+		{"символы после кавычки", "A='x'y", 1, errDotenvTail},
+		{"номер строки после многострочного значения", "A='x\ny'\n\nB='z'w", 4, errDotenvTail},
+	}
+	for _, tt := range tests {
+		_, err := dotenvParse("test.env", tt.in)
+		if err == nil {
+			t.Errorf("%s: разбор выполнен без ошибки", tt.name)
+			continue
+		}
+		prefix := fmt.Sprintf(errDotenvSyntax, "test.env", tt.line, "")
+		if msg := err.Error(); !strings.HasPrefix(msg, prefix) || !strings.Contains(msg, tt.err) {
+			t.Errorf("%s: ошибка %q, ожидается строка %d и %q", tt.name, msg, tt.line, tt.err)
+		}
+	}
+}
+
+// Приоритет переменных: окружение текущего процесса < файлы .env в порядке загрузки < Environment() <
+// изменения Setenv(), Unsetenv(), EnvironmentMap() и фильтры.
+func TestEnvironmentFilePrecedence(t *testing.T) {
+	var (
+		dir    = t.TempDir()
+		first  = filepath.Join(dir, "first.env")
+		second = filepath.Join(dir, "second.env")
+	)
+
+	t.Setenv("RUN_TEST_PROCESS", "process")
+	t.Setenv("RUN_TEST_FILE", "process")
+	writeFile(t, first, "RUN_TEST_FILE=first\nRUN_TEST_ORDER=first-${RUN_TEST_PROCESS}\nRUN_TEST_ENV=first\n")
+	writeFile(t, second, "RUN_TEST_ORDER=second-$RUN_TEST_ORDER\nRUN_TEST_SET=second\n")
+
+	tests := []struct {
+		name string
+		fn   func(run Interface)
+		out  map[string]string
+	}{
+		{
+			name: "окружение текущего процесса и файлы",
+			fn:   func(run Interface) { run.EnvironmentFile(first, second) },
+			out: map[string]string{
+				"RUN_TEST_PROCESS": "process",
+				"RUN_TEST_FILE":    "first",
+				"RUN_TEST_ORDER":   "second-first-process",
+				"RUN_TEST_ENV":     "first",
+				"RUN_TEST_SET":     "second",
+			},
+		},
+		{
+			name: "файлы, Environment() и Setenv()",
+			fn: func(run Interface) {
+				run.Setenv("RUN_TEST_SET", "set-${RUN_TEST_SET}").
+					Environment("RUN_TEST_ENV=env").
+					EnvironmentFile(first).
+					EnvironmentFile(second)
+			},
+			out: map[string]string{
+				"RUN_TEST_PROCESS": "",
+				"RUN_TEST_FILE":    "first",
+				"RUN_TEST_ORDER":   "second-first-",
+				"RUN_TEST_ENV":     "env",
+				"RUN_TEST_SET":     "set-second",
+			},
+		},
+		{
+			name: "Unsetenv() и фильтры",
+			fn: func(run Interface) {
+				run.EnvironmentFile(first, second).Unsetenv("RUN_TEST_FILE").EnvironmentKeep("RUN_TEST_")
+			},
+			out: map[string]string{
+				"RUN_TEST_PROCESS": "process",
+				"RUN_TEST_FILE":    "",
+				"RUN_TEST_ORDER":   "second-first-process",
+			},
+		},
+	}
+	for _, tt := range tests {
+		var run = New()
+
+		tt.fn(run)
+		if err := run.Error(); err != nil {
+			t.Fatalf("%s: ошибка загрузки файлов: %v", tt.name, err)
+		}
+		env := newEnvTable(run.ResolvedEnvironment())
+		for key, value := range tt.out {
+			if got, _ := env.get(key); got != value {
+				t.Errorf("%s: %s=%q, ожидается %q", tt.name, key, got, value)
+			}
+		}
+	}
+	if err := New().EnvironmentFile(filepath.Join(dir, "missing.env")).Error(); err == nil {
+		t.Errorf("загрузка отсутствующего файла выполнена без ошибки")
+	}
+}
+
+// Запись файла теста.
+func writeFile(t *testing.T, path string, data string) {
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("ошибка записи файла %q: %v", path, err)
+	}
+}
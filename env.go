@@ -0,0 +1,251 @@
+package run
+
+import (
+	"os"
+	"sort"
+	"strings"
+)
+
+// Таблица переменных окружения с сохранением порядка. Повторное значение переменной заменяет предыдущее
+// значение, сохраняя позицию первого появления переменной.
+type envTable struct {
+	keys   []string          // Порядок переменных.
+	values map[string]string // Значения переменных.
+}
+
+// Конструктор таблицы переменных окружения из списка "КЛЮЧ=Значение".
+func newEnvTable(env []string) (ret *envTable) {
+	ret = &envTable{keys: make([]string, 0, len(env)), values: make(map[string]string, len(env))}
+	ret.load(env)
+
+	return
+}
+
+// Установка переменных из списка "КЛЮЧ=Значение". Элементы без знака "=" пропускаются.
+func (et *envTable) load(env []string) {
+	for _, item := range env {
+		if key, value, ok := strings.Cut(item, "="); ok && key != "" {
+			et.set(key, value)
+		}
+	}
+}
+
+// Установка значения переменной.
+func (et *envTable) set(key string, value string) {
+	if _, ok := et.values[key]; !ok {
+		et.keys = append(et.keys, key)
+	}
+	et.values[key] = value
+}
+
+// Получение значения переменной.
+func (et *envTable) get(key string) (ret string, ok bool) { ret, ok = et.values[key]; return }
+
+// Удаление переменных, для которых функция возвращает истину.
+func (et *envTable) remove(fn func(key string) bool) {
+	var keys = et.keys[:0]
+
+	for _, key := range et.keys {
+		if fn(key) {
+			delete(et.values, key)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	et.keys = keys
+}
+
+// Список переменных окружения "КЛЮЧ=Значение".
+func (et *envTable) list() (ret []string) {
+	ret = make([]string, 0, len(et.keys))
+	for _, key := range et.keys {
+		ret = append(ret, key+"="+et.values[key])
+	}
+
+	return
+}
+
+// Подстановка значений переменных ${КЛЮЧ} из таблицы переменных окружения. Отсутствующая переменная
+// заменяется пустой строкой, ${КЛЮЧ:-по умолчанию} заменяется значением по умолчанию, если переменная
+// отсутствует или пуста, "$$" заменяется символом "$", остальные символы "$" сохраняются без изменений.
+func (et *envTable) expand(s string) string {
+	var (
+		buf        strings.Builder
+		end        int
+		key, value string
+		def        string
+		ok         bool
+	)
+
+	if !strings.Contains(s, "$") {
+		return s
+	}
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] != '$' || i+1 == len(s):
+			buf.WriteByte(s[i])
+		case s[i+1] == '$':
+			buf.WriteByte('$')
+			i++
+		case s[i+1] == '{':
+			if end = strings.IndexByte(s[i+2:], '}'); end < 0 {
+				buf.WriteString(s[i:])
+				return buf.String()
+			}
+			if key, def, ok = strings.Cut(s[i+2:i+2+end], ":-"); !ok {
+				def = ""
+			}
+			if value, _ = et.get(key); value == "" {
+				value = def
+			}
+			buf.WriteString(value)
+			i += end + 2
+		default:
+			buf.WriteByte(s[i])
+		}
+	}
+
+	return buf.String()
+}
+
+// Признак имени переменной, начинающегося с одного из префиксов.
+func envPrefixed(key string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Добавление изменения окружения процесса.
+func (run *impl) envChange(fn func(et *envTable), msg string, attrs ...Attr) Interface {
+	run.fieldSync.Lock()
+	run.envChanges = append(run.envChanges, fn)
+	run.fieldSync.Unlock()
+	run.log(LevelDebug, msg, attrs...)
+
+	return run
+}
+
+// EnvironmentInherit Выбор базового окружения процесса, к которому применяются изменения Setenv(), Unsetenv(),
+// EnvironmentMap() и фильтры: true - окружение текущего процесса (по умолчанию), false - пустое окружение.
+// Если переменные окружения установлены через Environment(), базовым окружением являются они.
+func (run *impl) EnvironmentInherit(inherit bool) Interface {
+	const msgInherit = "config.env.inherit"
+
+	run.fieldSync.Lock()
+	run.envClean = !inherit
+	run.fieldSync.Unlock()
+	run.log(LevelDebug, msgInherit, attr("inherit", inherit))
+
+	return run
+}
+
+// Setenv Установка переменной окружения процесса поверх базового окружения.
+// В значении выполняется подстановка ${КЛЮЧ} из окружения, полученного к моменту применения изменения,
+// например Setenv("PATH", "/opt/bin:${PATH}"). Для записи символа "$" перед "{" используется "$$".
+func (run *impl) Setenv(key string, value string) Interface {
+	const msgSetenv = "config.env.set"
+	return run.envChange(func(et *envTable) { et.set(key, et.expand(value)) }, msgSetenv, attr("key", key))
+}
+
+// Unsetenv Удаление переменных окружения процесса из базового окружения и ранее установленных переменных.
+func (run *impl) Unsetenv(keys ...string) Interface {
+	const msgUnsetenv = "config.env.unset"
+	var unset = append(make([]string, 0, len(keys)), keys...)
+	return run.envChange(func(et *envTable) {
+		et.remove(func(key string) bool {
+			for n := range unset {
+				if unset[n] == key {
+					return true
+				}
+			}
+			return false
+		})
+	}, msgUnsetenv, attr("keys", unset))
+}
+
+// EnvironmentMap Установка переменных окружения процесса из карты, аналогично Setenv() для каждой переменной.
+// Переменные устанавливаются в порядке сортировки имён.
+func (run *impl) EnvironmentMap(env map[string]string) Interface {
+	const msgMap = "config.env.map"
+	var (
+		keys   = make([]string, 0, len(env))
+		values = make(map[string]string, len(env))
+	)
+
+	for key, value := range env {
+		keys, values[key] = append(keys, key), value
+	}
+	sort.Strings(keys)
+	return run.envChange(func(et *envTable) {
+		for _, key := range keys {
+			et.set(key, et.expand(values[key]))
+		}
+	}, msgMap, attr("keys", keys))
+}
+
+// EnvironmentDrop Удаление переменных окружения процесса, имена которых начинаются с одного из префиксов,
+// например EnvironmentDrop("AWS_").
+func (run *impl) EnvironmentDrop(prefixes ...string) Interface {
+	const msgDrop = "config.env.drop"
+	var list = append(make([]string, 0, len(prefixes)), prefixes...)
+	return run.envChange(func(et *envTable) {
+		et.remove(func(key string) bool { return envPrefixed(key, list) })
+	}, msgDrop, attr("prefixes", list))
+}
+
+// EnvironmentKeep Сохранение только тех переменных окружения процесса, имена которых начинаются с одного из
+// префиксов, например EnvironmentKeep("LANG", "LC_", "PATH").
+func (run *impl) EnvironmentKeep(prefixes ...string) Interface {
+	const msgKeep = "config.env.keep"
+	var list = append(make([]string, 0, len(prefixes)), prefixes...)
+	return run.envChange(func(et *envTable) {
+		et.remove(func(key string) bool { return !envPrefixed(key, list) })
+	}, msgKeep, attr("prefixes", list))
+}
+
+// ResolvedEnvironment Окружение, которое получит процесс при запуске: базовое окружение с применёнными
+// изменениями, без повторяющихся переменных. Функции OnBeforeStart() могут изменить окружение при запуске.
+func (run *impl) ResolvedEnvironment() (ret []string) {
+	run.fieldSync.RLock()
+	ret = run.envResolve()
+	run.fieldSync.RUnlock()
+
+	return
+}
+
+// Окружение процесса. Функция вызывается под блокировкой.
+func (run *impl) envResolve() []string {
+	var et *envTable
+
+	// Окружение текущего процесса наследуется, если переменные окружения не установлены через Environment().
+	if run.attributes.Env == nil && !run.envClean {
+		et = newEnvTable(os.Environ())
+	} else {
+		et = newEnvTable(nil)
+	}
+	for _, entry := range run.envFiles {
+		et.set(entry.key, et.expand(entry.value))
+	}
+	et.load(run.attributes.Env)
+	for _, fn := range run.envChanges {
+		fn(et)
+	}
+
+	return et.list()
+}
+
+// Окружение процесса для запуска, nil - наследование окружения текущего процесса без изменений.
+// Изменения окружения применяются один раз: полученное окружение становится базовым окружением процесса.
+// Функция вызывается под блокировкой.
+func (run *impl) envStart() (ret []string) {
+	if run.attributes.Env == nil && !run.envClean && len(run.envChanges) == 0 && len(run.envFiles) == 0 {
+		return
+	}
+	ret = run.envResolve()
+	run.attributes.Env, run.envFiles, run.envChanges, run.envClean = ret, nil, nil, false
+
+	return
+}
@@ -0,0 +1,96 @@
+package run
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEnvExpand(t *testing.T) {
+	var et = newEnvTable([]string{"A=1", "B=two words", "E="})
+
+	tests := []struct {
+		name string
+		in   string
+		out  string
+	}{
+		{"без подстановок", "plain", "plain"},
+		{"переменная", "${A}", "1"},
+		{"переменная в тексте", "x${B}y", "xtwo wordsy"},
+		{"отсутствующая переменная", "[${MISSING}]", "[]"},
+		{"значение по умолчанию, переменная отсутствует", "${MISSING:-def}", "def"},
+		{"значение по умолчанию, переменная пуста", "${E:-def}", "def"},
+		{"значение по умолчанию не используется", "${A:-def}", "1"},
+		{"пустое значение по умолчанию", "${MISSING:-}", ""},
+		{"двойной знак доллара", "$$", "$"},
+		{"экранирование подстановки", "$${A}", "${A}"},
+		{"знак доллара перед подстановкой", "$$${A}", "$1"},
+		{"четыре знака доллара", "$$$${A}", "$${A}"},
+		{"переменная без скобок не подставляется", "$A", "$A"},
+		{"знак доллара в конце строки", "x$", "x$"},
+		{"незакрытая скобка", "a${A", "a${A"},
+		{"незакрытая скобка после подстановки", "${A}${B", "1${B"},
+	}
+	for _, tt := range tests {
+		if out := et.expand(tt.in); out != tt.out {
+			t.Errorf("%s: expand(%q) = %q, ожидается %q", tt.name, tt.in, out, tt.out)
+		}
+	}
+}
+
+func TestEnvironmentBuilder(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(run Interface)
+		out  []string
+	}{
+		{
+			name: "Environment() заменяет окружение текущего процесса",
+			fn:   func(run Interface) { run.Environment("A=1", "B=2", "A=3") },
+			out:  []string{"A=3", "B=2"},
+		},
+		{
+			name: "пустое базовое окружение",
+			fn:   func(run Interface) { run.EnvironmentInherit(false).Setenv("A", "1") },
+			out:  []string{"A=1"},
+		},
+		{
+			name: "Setenv() с подстановкой",
+			fn:   func(run Interface) { run.Environment("PATH=/bin").Setenv("PATH", "/opt/bin:${PATH}") },
+			out:  []string{"PATH=/opt/bin:/bin"},
+		},
+		{
+			name: "Unsetenv() удаляет переменные базового окружения и установленные ранее",
+			fn:   func(run Interface) { run.Environment("A=1", "B=2").Setenv("C", "3").Unsetenv("A", "C") },
+			out:  []string{"B=2"},
+		},
+		{
+			name: "Setenv() после Unsetenv()",
+			fn:   func(run Interface) { run.Environment("A=1").Unsetenv("A").Setenv("A", "[${A}]") },
+			out:  []string{"A=[]"},
+		},
+		{
+			name: "EnvironmentMap() в порядке сортировки имён",
+			fn: func(run Interface) {
+				run.Environment("X=x").EnvironmentMap(map[string]string{"B": "${A}", "A": "${X}"})
+			},
+			out: []string{"X=x", "A=x", "B=x"},
+		},
+		{
+			name: "фильтры",
+			fn: func(run Interface) {
+				run.Environment("AWS_KEY=1", "LANG=C", "LC_ALL=C", "HOME=/").
+					EnvironmentDrop("AWS_").
+					EnvironmentKeep("LANG", "LC_", "AWS_")
+			},
+			out: []string{"LANG=C", "LC_ALL=C"},
+		},
+	}
+	for _, tt := range tests {
+		var run = New()
+
+		tt.fn(run)
+		if out := run.ResolvedEnvironment(); !reflect.DeepEqual(out, tt.out) {
+			t.Errorf("%s: окружение %q, ожидается %q", tt.name, out, tt.out)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package run
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"time"
+)
+
+// EventType Тип события жизненного цикла процесса.
+type EventType uint32
+
+const (
+	// EventBeforeStart Подготовка к запуску процесса. Событие передаётся только функциям OnBeforeStart().
+	EventBeforeStart EventType = iota
+
+	// EventStarted Процесс запущен.
+	EventStarted
+
+	// EventStdinClosed Поток STDIN процесса закрыт.
+	EventStdinClosed
+
+	// EventSignalSent Процессу отправлен сигнал.
+	EventSignalSent
+
+	// EventExited Процесс завершён, все данные потоков STDOUT и STDERR получены.
+	EventExited
+
+	// EventReleased Ресурсы процесса освобождены.
+	EventReleased
+
+	// EventReset Выполнен сброс пакета для повторного использования.
+	EventReset
+)
+
+// String Реализация интерфейса fmt.Stringer.
+func (et EventType) String() string {
+	switch et {
+	case EventBeforeStart:
+		return "before_start"
+	case EventStarted:
+		return "started"
+	case EventStdinClosed:
+		return "stdin_closed"
+	case EventSignalSent:
+		return "signal_sent"
+	case EventExited:
+		return "exited"
+	case EventReleased:
+		return "released"
+	case EventReset:
+		return "reset"
+	default:
+		return "event(" + strconv.FormatUint(uint64(et), 10) + ")"
+	}
+}
+
+// Event Событие жизненного цикла процесса.
+type Event struct {
+	Type   EventType // Тип события.
+	Time   time.Time // Время события.
+	Pid    int       // Идентификатор процесса, или -1, если процесс не запущен.
+	Args   []string  // Команда запуска процесса.
+	Signal os.Signal // Отправленный сигнал, для события EventSignalSent.
+	Result *Result   // Результат выполнения процесса, для события EventExited.
+	Err    error     // Ошибка, связанная с событием.
+}
+
+// StartRequest Параметры запуска процесса, передаваемые функциям OnBeforeStart().
+// Функции могут изменить параметры запуска, изменения применяются к запускаемому процессу.
+type StartRequest struct {
+	Args []string // Команда и аргументы запуска.
+	Env  []string // Переменные окружения, nil означает наследование окружения текущего процесса.
+	Dir  string   // Рабочая директория.
+}
+
+// OnBeforeStart Регистрация функции, вызываемой перед запуском процесса.
+// Функция может изменить параметры запуска, или отменить запуск, вернув ошибку. Ошибка становится ошибкой
+// запуска, доступной через Error(). Функции вызываются в порядке регистрации и сохраняются при вызове Reset().
+func (run *impl) OnBeforeStart(fn func(req *StartRequest) error) Interface {
+	run.eventSync.Lock()
+	run.hooksBeforeStart = append(run.hooksBeforeStart, fn)
+	run.eventSync.Unlock()
+
+	return run
+}
+
+// OnEvent Регистрация функции, вызываемой при каждом событии жизненного цикла процесса, кроме EventBeforeStart.
+// Функции вызываются синхронно, в горутине в которой произошло событие, в порядке регистрации, и сохраняются
+// при вызове Reset(). Функция не должна блокироваться на продолжительное время и не должна синхронно вызывать
+// Run(), RunWait(), Wait() и Reset(). Событие EventStarted всегда предшествует событиям EventStdinClosed и
+// EventExited этого же процесса, а функция Wait() возвращает управление после обработки события EventExited.
+func (run *impl) OnEvent(fn func(ev Event)) Interface {
+	run.eventSync.Lock()
+	run.hooks = append(run.hooks, fn)
+	run.eventSync.Unlock()
+
+	return run
+}
+
+// Events Канал событий жизненного цикла процесса, кроме EventBeforeStart.
+// События отправляются в канал в порядке их возникновения. Отправка событий не блокирует работу пакета:
+// если буфер канала заполнен, событие не отправляется в канал. Канал закрывается после прерывания через
+// контекст, канал сохраняется при вызове Reset(). Если контекст равен nil, возвращается закрытый канал,
+// так как подписку без контекста невозможно отменить. Подписка с контекстом, который не прерывается,
+// например context.Background(), действует до конца жизни объекта.
+func (run *impl) Events(ctx context.Context) (ret <-chan Event) {
+	const msgEvents = "events.subscribe"
+	var ch chan Event
+
+	if ctx == nil {
+		ch = make(chan Event)
+		close(ch)
+		return ch
+	}
+	_, chanLen := run.sizeGet()
+	ch = make(chan Event, chanLen)
+	run.eventSync.Lock()
+	run.eventSubs = append(run.eventSubs, ch)
+	run.eventSync.Unlock()
+	run.log(LevelDebug, msgEvents)
+	if ctx.Done() != nil {
+		go func() {
+			<-ctx.Done()
+			run.eventSync.Lock()
+			defer run.eventSync.Unlock()
+			for n := range run.eventSubs {
+				if run.eventSubs[n] == ch {
+					run.eventSubs = append(run.eventSubs[:n], run.eventSubs[n+1:]...)
+					close(ch)
+					break
+				}
+			}
+		}()
+	}
+	ret = ch
+
+	return
+}
+
+// Вызов функций, зарегистрированных через OnBeforeStart().
+func (run *impl) fireBeforeStart(req *StartRequest) (err error) {
+	var hooks []func(req *StartRequest) error
+
+	run.eventSync.Lock()
+	hooks = append(hooks, run.hooksBeforeStart...)
+	run.eventSync.Unlock()
+	for _, fn := range hooks {
+		if err = fn(req); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// Отправка события функциям OnEvent() и в каналы событий.
+func (run *impl) fire(ev Event) {
+	const msgDropped = "events.dropped"
+	var hooks []func(ev Event)
+
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	run.eventSync.Lock()
+	hooks = append(hooks, run.hooks...)
+	for _, ch := range run.eventSubs {
+		select {
+		case ch <- ev:
+		default:
+			run.log(LevelWarn, msgDropped, attr("event", ev.Type), attr("pid", ev.Pid))
+		}
+	}
+	run.eventSync.Unlock()
+	for _, fn := range hooks {
+		fn(ev)
+	}
+}
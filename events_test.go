@@ -0,0 +1,43 @@
+package run
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestEvents(t *testing.T) {
+	var (
+		run         = New().(*impl)
+		ctx, cancel = context.WithCancel(context.Background())
+		types       []EventType
+	)
+
+	if _, ok := <-run.Events(nil); ok {
+		t.Errorf("канал событий без контекста не закрыт")
+	}
+	events := run.Events(ctx)
+	if _, err := run.RunWait(context.Background(), "true"); err != nil {
+		t.Fatalf("выполнение процесса прервано ошибкой: %v", err)
+	}
+	for len(events) > 0 {
+		types = append(types, (<-events).Type)
+	}
+	if len(types) < 3 || types[0] != EventStarted || types[len(types)-1] != EventExited {
+		t.Errorf("получены события %v, ожидаются started, ..., exited", types)
+	}
+	cancel()
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Errorf("получено событие после прерывания через контекст")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("канал событий не закрыт после прерывания через контекст")
+	}
+	run.eventSync.Lock()
+	if len(run.eventSubs) != 0 {
+		t.Errorf("подписка на события не удалена после прерывания через контекст")
+	}
+	run.eventSync.Unlock()
+}
@@ -0,0 +1,143 @@
+package run
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	listenFdsStart = 3         // Номер первого файлового дескриптора сокета, SD_LISTEN_FDS_START.
+	listenShell    = "/bin/sh" // Командный интерпретатор, устанавливающий переменную окружения LISTEN_PID.
+	listenName     = "unknown" // Имя сокета, если имя не указано.
+	listenPid      = "LISTEN_PID"
+	listenFds      = "LISTEN_FDS"
+	listenFdNames  = "LISTEN_FDNAMES"
+	// Значение LISTEN_PID должно совпадать с PID процесса, который становится известен только после запуска,
+	// поэтому процесс запускается через командный интерпретатор, который устанавливает переменную окружения
+	// и заменяет себя процессом через exec, сохраняя PID.
+	listenScript = `LISTEN_PID=$$; export LISTEN_PID; exec "$@"`
+)
+
+// ActivationSocket Сокет, передаваемый процессу по протоколу активации сокетов systemd.
+type ActivationSocket struct {
+	Name string   // Имя сокета, передаваемое в LISTEN_FDNAMES, не должно содержать символ ':'.
+	File *os.File // Файл сокета.
+}
+
+// ListenerFile Получение копии файла сокета, на котором слушает net.Listener, для передачи процессу.
+// Закрытие полученного файла не закрывает net.Listener.
+func ListenerFile(l net.Listener) (ret *os.File, err error) {
+	const errListener = "тип %T не позволяет получить файл сокета"
+	var (
+		fl interface{ File() (*os.File, error) }
+		ok bool
+	)
+
+	if fl, ok = l.(interface{ File() (*os.File, error) }); !ok {
+		err = fmt.Errorf(errListener, l)
+		return
+	}
+	ret, err = fl.File()
+
+	return
+}
+
+// ExtraFiles Дополнительные файлы, передаваемые процессу как файловые дескрипторы, начиная с 3.
+// Если указаны сокеты активации, дополнительные файлы передаются после них. Значение nil означает, что
+// соответствующий файловый дескриптор в процессе закрыт. Файлы не закрываются пакетом.
+func (run *impl) ExtraFiles(files ...*os.File) Interface {
+	const msgExtraFiles = "config.files"
+
+	run.fieldSync.Lock()
+	run.extraFiles = append(make([]*os.File, 0, len(files)), files...)
+	run.fieldSync.Unlock()
+	run.log(LevelDebug, msgExtraFiles, attr("count", len(files)))
+
+	return run
+}
+
+// SocketActivation Передача процессу сокетов по протоколу активации сокетов systemd: сокеты передаются как
+// файловые дескрипторы, начиная с 3, а процессу устанавливаются переменные окружения LISTEN_FDS,
+// LISTEN_FDNAMES и LISTEN_PID. Для установки LISTEN_PID процесс запускается через /bin/sh, который должен
+// быть доступен, в том числе в chroot. Файлы не закрываются пакетом.
+func (run *impl) SocketActivation(sockets ...ActivationSocket) Interface {
+	const msgSockets = "config.sockets"
+	var names = make([]string, 0, len(sockets))
+
+	run.fieldSync.Lock()
+	run.sockets = append(make([]ActivationSocket, 0, len(sockets)), sockets...)
+	run.fieldSync.Unlock()
+	for n := range sockets {
+		names = append(names, sockets[n].Name)
+	}
+	run.log(LevelDebug, msgSockets, attr("count", len(sockets)), attr("names", names))
+
+	return run
+}
+
+// Добавление сокетов активации и дополнительных файлов в атрибуты запуска процесса.
+// Если переданы сокеты активации, в окружение процесса добавляются переменные протокола активации сокетов,
+// а возвращаемые программа и аргументы запускают процесс через командный интерпретатор.
+func (run *impl) filesApply(proc string, argv []string) (retProc string, retArgv []string, err error) {
+	const (
+		errName = "недопустимое имя сокета %q, имя не должно содержать символ ':'"
+		errFile = "не указан файл сокета %q"
+	)
+	var (
+		names []string
+		env   []string
+	)
+
+	run.fieldSync.Lock()
+	defer run.fieldSync.Unlock()
+	retProc, retArgv = proc, argv
+	run.attributes.Files = run.attributes.Files[:listenFdsStart]
+	if len(run.sockets) > 0 {
+		names = make([]string, 0, len(run.sockets))
+		for n := range run.sockets {
+			switch {
+			case strings.Contains(run.sockets[n].Name, ":"):
+				err = fmt.Errorf(errName, run.sockets[n].Name)
+				return
+			case run.sockets[n].File == nil:
+				err = fmt.Errorf(errFile, run.sockets[n].Name)
+				return
+			case run.sockets[n].Name == "":
+				names = append(names, listenName)
+			default:
+				names = append(names, run.sockets[n].Name)
+			}
+			run.attributes.Files = append(run.attributes.Files, run.sockets[n].File)
+		}
+		// Переменные протокола активации, унаследованные от текущего процесса, заменяются.
+		if env = run.attributes.Env; env == nil {
+			env = os.Environ()
+		}
+		run.attributes.Env = make([]string, 0, len(env)+2)
+		for _, item := range env {
+			switch {
+			case strings.HasPrefix(item, listenPid+"="),
+				strings.HasPrefix(item, listenFds+"="),
+				strings.HasPrefix(item, listenFdNames+"="):
+				continue
+			}
+			run.attributes.Env = append(run.attributes.Env, item)
+		}
+		run.attributes.Env = append(run.attributes.Env,
+			listenFds+"="+strconv.Itoa(len(run.sockets)),
+			listenFdNames+"="+strings.Join(names, ":"),
+		)
+		retProc = listenShell
+		retArgv = append([]string{listenShell, "-c", listenScript, proc}, argv...)
+	}
+	run.attributes.Files = append(run.attributes.Files, run.extraFiles...)
+	// Файл сценария RunScript() передаётся последним, его номер вычислен при запуске сценария.
+	if run.scriptFile != nil {
+		run.attributes.Files = append(run.attributes.Files, run.scriptFile)
+	}
+
+	return
+}
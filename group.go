@@ -0,0 +1,263 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+)
+
+const (
+	errGroupClosed = "группа процессов закрыта, добавление заданий невозможно"
+	errGroupExit   = "задание %d: процесс %q завершён: %s"
+)
+
+// GroupOptions Настройки группы процессов.
+type GroupOptions struct {
+	Concurrency int  // Количество одновременно выполняемых процессов, 0 - количество процессоров runtime.NumCPU().
+	FailFast    bool // Первое неудачное задание отменяет группу: процессы завершаются, ожидающие задания не запускаются.
+	Ordered     bool // Results() возвращает результаты в порядке добавления заданий, а не в порядке завершения.
+}
+
+// GroupResult Результат выполнения задания группы процессов.
+type GroupResult struct {
+	Index int       // Порядковый номер задания в группе, начиная с 0.
+	Spec  Spec      // Описание запуска задания.
+	Run   Interface // Объект последнего запущенного процесса, nil если задание не запускалось из-за отмены группы.
+	Err   error     // Ошибка запуска или выполнения процесса, в том числе завершение с ненулевым кодом.
+}
+
+// GroupStats Сводная статистика группы процессов.
+type GroupStats struct {
+	Submitted  int           // Количество добавленных заданий.
+	Running    int           // Количество выполняющихся заданий.
+	Succeeded  int           // Количество успешно завершившихся заданий.
+	Failed     int           // Количество заданий, завершившихся ошибкой, в том числе прерванных отменой группы.
+	Canceled   int           // Количество заданий, не запускавшихся из-за отмены группы.
+	UserTime   time.Duration // Суммарное время выполнения процессов в режиме пользователя.
+	SystemTime time.Duration // Суммарное время выполнения процессов в режиме ядра.
+}
+
+// Group Группа процессов, выполняемых параллельно с ограничением количества одновременно выполняемых процессов.
+// Задания добавляются функцией Submit() и запускаются через Spec.RunWait(), поэтому учитывают максимальное время
+// выполнения и политику перезапуска описания. Отмена контекста группы или вызов Cancel() завершает выполняющиеся
+// процессы аналогично вызову Kill(), а ожидающие задания не запускаются. Функции группы безопасны для
+// одновременного вызова из разных горутин.
+// Группа использует горутину, ожидающую отмены группы, которая завершается после отмены контекста группы,
+// вызова Cancel() или Wait(), либо после вызова Close() и завершения всех заданий. Если ни одно из этих
+// событий не наступает, горутина и контекст группы не освобождаются.
+type Group struct {
+	ctx     context.Context    // Общий контекст процессов группы.
+	cancel  context.CancelFunc // Функция отмены группы.
+	opt     GroupOptions       // Настройки.
+	sync    *sync.Mutex        // Контроль монопольного доступа к полям группы.
+	cond    *sync.Cond         // Сигнал о завершении задания или закрытии группы.
+	queue   []*GroupResult     // Задания, ожидающие запуска, в порядке добавления.
+	results []*GroupResult     // Результаты в порядке добавления заданий, nil для незавершённых заданий.
+	order   []int              // Порядковые номера заданий в порядке завершения.
+	pending int                // Количество незавершённых заданий.
+	closed  bool               // Добавление заданий завершено.
+	err     error              // Первая ошибка в порядке завершения заданий.
+	stats   GroupStats         // Сводная статистика.
+}
+
+// NewGroup Конструктор группы процессов. Если opt равен nil, используются настройки по умолчанию:
+// количество процессов по количеству процессоров, выполнение всех заданий и результаты в порядке завершения.
+// Ресурсы группы освобождаются после вызова Wait() или Cancel(), отмены контекста ctx, либо после вызова
+// Close() и завершения всех заданий.
+func NewGroup(ctx context.Context, opt *GroupOptions) (ret *Group) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ret = &Group{sync: new(sync.Mutex)}
+	if opt != nil {
+		ret.opt = *opt
+	}
+	if ret.opt.Concurrency <= 0 {
+		ret.opt.Concurrency = runtime.NumCPU()
+	}
+	ret.ctx, ret.cancel = context.WithCancel(ctx)
+	ret.cond = sync.NewCond(ret.sync)
+	// После отмены группы ожидающие задания завершаются без запуска.
+	go func() {
+		<-ret.ctx.Done()
+		ret.sync.Lock()
+		ret.dispatch()
+		ret.sync.Unlock()
+	}()
+
+	return
+}
+
+// Submit Добавление задания в группу, задание запускается, когда освобождается место для процесса.
+// Возвращается порядковый номер задания. После вызова Close() или Wait() добавление заданий невозможно.
+func (g *Group) Submit(spec Spec) (ret int, err error) {
+	g.sync.Lock()
+	if g.closed {
+		g.sync.Unlock()
+		return -1, errors.New(errGroupClosed)
+	}
+	ret = len(g.results)
+	g.results = append(g.results, nil)
+	g.pending++
+	g.stats.Submitted++
+	g.queue = append(g.queue, &GroupResult{Index: ret, Spec: spec.Clone()})
+	g.dispatch()
+	g.sync.Unlock()
+
+	return
+}
+
+// Close Завершение добавления заданий, после которого каналы Results() закрываются по мере выдачи результатов.
+func (g *Group) Close() {
+	g.sync.Lock()
+	g.closed = true
+	g.cond.Broadcast()
+	g.finish()
+	g.sync.Unlock()
+}
+
+// Cancel Отмена группы: выполняющиеся процессы завершаются, ожидающие задания не запускаются.
+func (g *Group) Cancel() { g.cancel() }
+
+// Wait Завершение добавления заданий и ожидание завершения всех заданий группы.
+// Возвращаются результаты в порядке добавления заданий и первая ошибка в порядке завершения заданий.
+func (g *Group) Wait() (ret []GroupResult, err error) {
+	g.Close()
+	g.sync.Lock()
+	for g.pending > 0 {
+		g.cond.Wait()
+	}
+	ret = make([]GroupResult, 0, len(g.results))
+	for _, result := range g.results {
+		ret = append(ret, *result)
+	}
+	err = g.err
+	g.sync.Unlock()
+	g.cancel()
+
+	return
+}
+
+// Results Канал результатов заданий в порядке завершения или, если установлена настройка Ordered, в порядке
+// добавления. Канал закрывается после вызова Close() или Wait() и выдачи всех результатов, поэтому его следует
+// читать до закрытия. Каждый вызов возвращает новый канал со всеми результатами группы.
+func (g *Group) Results() <-chan GroupResult {
+	var ch = make(chan GroupResult)
+
+	go func() {
+		var (
+			result GroupResult
+			ready  bool
+		)
+
+		defer close(ch)
+		for n := 0; ; n++ {
+			g.sync.Lock()
+			for ready = g.ready(n); !ready && !(g.closed && n >= len(g.results)); ready = g.ready(n) {
+				g.cond.Wait()
+			}
+			if ready {
+				if g.opt.Ordered {
+					result = *g.results[n]
+				} else {
+					result = *g.results[g.order[n]]
+				}
+			}
+			g.sync.Unlock()
+			if !ready {
+				return
+			}
+			ch <- result
+		}
+	}()
+
+	return ch
+}
+
+// Stats Сводная статистика группы процессов на момент вызова.
+func (g *Group) Stats() (ret GroupStats) {
+	g.sync.Lock()
+	ret = g.stats
+	g.sync.Unlock()
+
+	return
+}
+
+// Готовность n-го результата для выдачи в канал Results(), вызывается под блокировкой.
+func (g *Group) ready(n int) bool {
+	if g.opt.Ordered {
+		return n < len(g.results) && g.results[n] != nil
+	}
+
+	return n < len(g.order)
+}
+
+// Запуск заданий из очереди в порядке добавления, пока есть место для процесса, вызывается под блокировкой.
+// После отмены группы задания из очереди завершаются без запуска.
+func (g *Group) dispatch() {
+	var result *GroupResult
+
+	for len(g.queue) > 0 && (g.stats.Running < g.opt.Concurrency || g.ctx.Err() != nil) {
+		result, g.queue = g.queue[0], g.queue[1:]
+		if g.ctx.Err() != nil {
+			result.Err = g.ctx.Err()
+			g.store(result)
+			g.stats.Canceled++
+			continue
+		}
+		g.stats.Running++
+		go g.execute(result)
+	}
+}
+
+// Выполнение задания группы.
+func (g *Group) execute(result *GroupResult) {
+	var res *Result
+
+	result.Run, result.Err = result.Spec.RunWait(g.ctx)
+	if res = result.Run.Result(); result.Err == nil && res != nil && res.ExitCode != 0 {
+		result.Err = fmt.Errorf(errGroupExit, result.Index, result.Spec.Args[0], res.State)
+	}
+	g.sync.Lock()
+	g.stats.Running--
+	if result.Err == nil {
+		g.stats.Succeeded++
+	} else {
+		g.stats.Failed++
+	}
+	if res != nil && res.Usage != nil {
+		g.stats.UserTime += res.Usage.UserTime
+		g.stats.SystemTime += res.Usage.SystemTime
+	}
+	g.store(result)
+	// Первое неудачное задание отменяет группу до запуска следующего задания из очереди.
+	if result.Err != nil && g.opt.FailFast {
+		g.cancel()
+	}
+	g.dispatch()
+	g.sync.Unlock()
+}
+
+// Сохранение результата завершённого задания, вызывается под блокировкой.
+func (g *Group) store(result *GroupResult) {
+	g.results[result.Index] = result
+	g.order = append(g.order, result.Index)
+	g.pending--
+	if g.err == nil {
+		g.err = result.Err
+	}
+	g.cond.Broadcast()
+	g.finish()
+}
+
+// Освобождение контекста и горутины отмены группы после закрытия группы и завершения всех заданий,
+// вызывается под блокировкой. Новые задания в закрытую группу не добавляются, поэтому отмена контекста
+// не влияет на задания.
+func (g *Group) finish() {
+	if g.closed && g.pending == 0 {
+		g.cancel()
+	}
+}
@@ -0,0 +1,217 @@
+package run
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Проверка сводной статистики завершённой группы.
+func testGroupStats(t *testing.T, g *Group, succeeded, failed, canceled int) {
+	var st = g.Stats()
+
+	if st.Running != 0 || st.Succeeded != succeeded || st.Failed != failed || st.Canceled != canceled {
+		t.Errorf("статистика %+v, ожидается успешных %d, неудачных %d, отменённых %d", st, succeeded, failed, canceled)
+	}
+	if st.Canceled+st.Failed+st.Succeeded != st.Submitted {
+		t.Errorf("сумма отменённых, неудачных и успешных заданий %d не равна количеству заданий %d",
+			st.Canceled+st.Failed+st.Succeeded, st.Submitted)
+	}
+}
+
+// Добавление заданий в группу.
+func testGroupSubmit(t *testing.T, g *Group, specs ...Spec) {
+	for n, spec := range specs {
+		if index, err := g.Submit(spec); err != nil || index != n {
+			t.Fatalf("Submit() = %d, %v, ожидается %d", index, err, n)
+		}
+	}
+}
+
+// Результаты выдаются в порядке завершения заданий, а с настройкой Ordered - в порядке добавления.
+func TestGroupResultsOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		ordered bool
+		out     []int
+	}{
+		{"порядок завершения", false, []int{1, 2, 0}},
+		{"порядок добавления", true, []int{0, 1, 2}},
+	}
+	for _, tt := range tests {
+		var (
+			g   = NewGroup(context.Background(), &GroupOptions{Concurrency: 3, Ordered: tt.ordered})
+			out []int
+		)
+
+		ch := g.Results()
+		testGroupSubmit(t, g,
+			Spec{Args: []string{"sleep", "0.6"}},
+			Spec{Args: []string{"sleep", "0.1"}},
+			Spec{Args: []string{"sleep", "0.3"}},
+		)
+		g.Close()
+		for result := range ch {
+			if result.Err != nil || result.Run == nil {
+				t.Errorf("%s: задание %d завершено ошибкой: %v", tt.name, result.Index, result.Err)
+			}
+			out = append(out, result.Index)
+		}
+		if !reflect.DeepEqual(out, tt.out) {
+			t.Errorf("%s: результаты в порядке %v, ожидается %v", tt.name, out, tt.out)
+		}
+		results, err := g.Wait()
+		if err != nil || len(results) != 3 {
+			t.Errorf("%s: Wait() = %d результатов, %v", tt.name, len(results), err)
+		}
+		for n := range results {
+			if results[n].Index != n {
+				t.Errorf("%s: Wait() вернула результат %d на позиции %d", tt.name, results[n].Index, n)
+			}
+		}
+		testGroupStats(t, g, 3, 0, 0)
+	}
+}
+
+// Первое неудачное задание отменяет группу, если установлена настройка FailFast, иначе выполняются все задания.
+func TestGroupFailFast(t *testing.T) {
+	tests := []struct {
+		name      string
+		failFast  bool
+		succeeded int
+		failed    int
+		canceled  int
+	}{
+		// Выполняющийся процесс sleep завершается отменой группы, последнее задание не запускается.
+		{"отмена группы", true, 0, 2, 1},
+		{"выполнение всех заданий", false, 2, 1, 0},
+	}
+	for _, tt := range tests {
+		var (
+			g     = NewGroup(context.Background(), &GroupOptions{Concurrency: 2, FailFast: tt.failFast})
+			begin = time.Now()
+		)
+
+		testGroupSubmit(t, g,
+			Spec{Args: []string{"sh", "-c", "sleep 0.1; exit 3"}},
+			Spec{Args: []string{"sleep", "1"}},
+			Spec{Args: []string{"true"}},
+		)
+		results, err := g.Wait()
+		if err == nil || results[0].Err == nil || err.Error() != results[0].Err.Error() {
+			t.Errorf("%s: Wait() вернула ошибку %v, ожидается ошибка первого задания", tt.name, err)
+		}
+		if tt.failFast {
+			if elapsed := time.Since(begin); elapsed >= time.Second {
+				t.Errorf("%s: группа отменена через %s", tt.name, elapsed)
+			}
+			if results[2].Run != nil || results[2].Err != context.Canceled {
+				t.Errorf("%s: отменённое задание: Run = %v, Err = %v", tt.name, results[2].Run, results[2].Err)
+			}
+		} else if results[1].Err != nil || results[2].Err != nil {
+			t.Errorf("%s: задания завершены ошибками: %v, %v", tt.name, results[1].Err, results[2].Err)
+		}
+		testGroupStats(t, g, tt.succeeded, tt.failed, tt.canceled)
+	}
+}
+
+// Отмена контекста группы завершает выполняющиеся процессы, а ожидающие задания завершаются без запуска.
+func TestGroupCancel(t *testing.T) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		g           = NewGroup(ctx, &GroupOptions{Concurrency: 1})
+		out         []GroupResult
+	)
+
+	ch := g.Results()
+	testGroupSubmit(t, g,
+		Spec{Args: []string{"sleep", "30"}},
+		Spec{Args: []string{"true"}},
+		Spec{Args: []string{"true"}},
+	)
+	time.AfterFunc(100*time.Millisecond, cancel)
+	// Каналы результатов закрываются после закрытия группы без вызова Wait().
+	g.Close()
+	for result := range ch {
+		out = append(out, result)
+	}
+	if len(out) != 3 {
+		t.Fatalf("получено результатов %d, ожидается 3", len(out))
+	}
+	for _, result := range out {
+		if result.Err == nil {
+			t.Errorf("задание %d завершено без ошибки", result.Index)
+		}
+		if result.Index > 0 && result.Run != nil {
+			t.Errorf("задание %d запущено после отмены группы", result.Index)
+		}
+	}
+	testGroupStats(t, g, 0, 1, 2)
+	if _, err := g.Submit(Spec{Args: []string{"true"}}); err == nil {
+		t.Errorf("задание добавлено в закрытую группу")
+	}
+}
+
+// Количество одновременно выполняемых процессов не превышает ограничения группы.
+func TestGroupConcurrency(t *testing.T) {
+	const concurrency, jobs = 2, 6
+	var (
+		g     = NewGroup(context.Background(), &GroupOptions{Concurrency: concurrency})
+		begin = time.Now()
+		done  = make(chan struct{})
+		wg    sync.WaitGroup
+		max   int
+	)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			if st := g.Stats(); st.Running > max {
+				max = st.Running
+			}
+			select {
+			case <-done:
+				return
+			case <-time.After(5 * time.Millisecond):
+			}
+		}
+	}()
+	for n := 0; n < jobs; n++ {
+		if _, err := g.Submit(Spec{Args: []string{"sleep", "0.1"}}); err != nil {
+			t.Fatalf("ошибка добавления задания: %v", err)
+		}
+	}
+	if _, err := g.Wait(); err != nil {
+		t.Errorf("выполнение группы прервано ошибкой: %v", err)
+	}
+	close(done)
+	wg.Wait()
+	if max > concurrency || max == 0 {
+		t.Errorf("одновременно выполнялось процессов %d, ограничение %d", max, concurrency)
+	}
+	if elapsed := time.Since(begin); elapsed < jobs/concurrency*100*time.Millisecond {
+		t.Errorf("задания выполнены за %s, ограничение количества процессов не соблюдается", elapsed)
+	}
+	testGroupStats(t, g, jobs, 0, 0)
+}
+
+// Контекст группы освобождается после закрытия группы и завершения всех заданий без вызова Wait().
+func TestGroupRelease(t *testing.T) {
+	var g = NewGroup(context.Background(), nil)
+
+	testGroupSubmit(t, g, Spec{Args: []string{"true"}})
+	for result := range func() <-chan GroupResult { ch := g.Results(); g.Close(); return ch }() {
+		if result.Err != nil {
+			t.Errorf("задание завершено ошибкой: %v", result.Err)
+		}
+	}
+	select {
+	case <-g.ctx.Done():
+	case <-time.After(time.Second):
+		t.Errorf("контекст группы не освобождён после завершения всех заданий")
+	}
+	testGroupStats(t, g, 1, 0, 0)
+}
@@ -0,0 +1,154 @@
+package run
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Level Уровень журналирования. Значения уровней совпадают со значениями уровней пакета log/slog.
+type Level int
+
+const (
+	// LevelDebug Отладочные сообщения.
+	LevelDebug Level = -4
+
+	// LevelInfo Информационные сообщения.
+	LevelInfo Level = 0
+
+	// LevelWarn Предупреждения.
+	LevelWarn Level = 4
+
+	// LevelError Ошибки.
+	LevelError Level = 8
+)
+
+// String Реализация интерфейса fmt.Stringer.
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	default:
+		return "LEVEL(" + strconv.Itoa(int(l)) + ")"
+	}
+}
+
+// Attr Структурированный атрибут сообщения журнала.
+type Attr struct {
+	Key   string // Ключ атрибута.
+	Value any    // Значение атрибута.
+}
+
+// Logger Интерфейс журналирования.
+// Сообщение msg является стабильным ключом события на английском языке, например "process.started",
+// подробности события передаются структурированными атрибутами.
+type Logger interface {
+	// Log Запись сообщения в журнал.
+	Log(level Level, msg string, attrs ...Attr)
+}
+
+// LoggerFunc Адаптер, позволяющий использовать функцию в качестве Logger.
+type LoggerFunc func(level Level, msg string, attrs ...Attr)
+
+// Log Запись сообщения в журнал.
+func (fn LoggerFunc) Log(level Level, msg string, attrs ...Attr) { fn(level, msg, attrs...) }
+
+// Текстовый журнал.
+type textLogger struct {
+	level Level       // Минимальный уровень записываемых сообщений.
+	out   io.Writer   // Получатель сообщений.
+	sync  *sync.Mutex // Контроль монопольного доступа к получателю сообщений.
+}
+
+// NewLogger Конструктор текстового журнала, записывающего сообщения с уровнем не ниже указанного.
+// Сообщения записываются построчно в формате "time=... level=... msg=... ключ=значение".
+func NewLogger(w io.Writer, level Level) Logger {
+	return &textLogger{level: level, out: w, sync: new(sync.Mutex)}
+}
+
+// Log Запись сообщения в журнал.
+func (tl *textLogger) Log(level Level, msg string, attrs ...Attr) {
+	var buf *bytes.Buffer
+
+	if level < tl.level {
+		return
+	}
+	buf = &bytes.Buffer{}
+	_, _ = fmt.Fprintf(buf, "time=%s level=%s msg=%s", time.Now().Format(time.RFC3339Nano), level, msg)
+	for _, a := range attrs {
+		_, _ = fmt.Fprintf(buf, " %s=%s", a.Key, logValue(a.Value))
+	}
+	_ = buf.WriteByte('\n')
+	tl.sync.Lock()
+	_, _ = tl.out.Write(buf.Bytes())
+	tl.sync.Unlock()
+}
+
+// Представление значения атрибута в текстовом журнале.
+func logValue(value any) (ret string) {
+	switch v := value.(type) {
+	case string:
+		ret = v
+	case error:
+		ret = v.Error()
+	default:
+		ret = fmt.Sprint(v)
+	}
+	if ret == "" || bytes.ContainsAny([]byte(ret), " \t\n\"=") {
+		ret = strconv.Quote(ret)
+	}
+
+	return
+}
+
+// Создание атрибута сообщения журнала.
+func attr(key string, value any) Attr { return Attr{Key: key, Value: value} }
+
+// Logger Установка журнала. Значение nil отключает журналирование.
+// Журнал сохраняется при вызове функции Reset().
+func (run *impl) Logger(logger Logger) Interface {
+	run.logSync.Lock()
+	run.logger = logger
+	run.logSync.Unlock()
+
+	return run
+}
+
+// Debug Установка режима отладки.
+// Режим отладки определяет, передаются ли в журнал отладочные сообщения, установленный журнал не заменяется.
+// Если журнал не установлен, включение режима отладки устанавливает текстовый журнал, записывающий все сообщения
+// в STDERR. Режим отладки сохраняется при вызове функции Reset().
+func (run *impl) Debug(isDebug bool) Interface {
+	run.logSync.Lock()
+	defer run.logSync.Unlock()
+	if run.logDebugOff = !isDebug; isDebug && run.logger == nil {
+		run.logger = NewLogger(os.Stderr, LevelDebug)
+	}
+
+	return run
+}
+
+// Запись сообщения в журнал, если журнал установлен.
+func (run *impl) log(level Level, msg string, attrs ...Attr) {
+	var logger Logger
+
+	run.logSync.RLock()
+	if logger = run.logger; level <= LevelDebug && run.logDebugOff {
+		logger = nil
+	}
+	run.logSync.RUnlock()
+	if logger == nil {
+		return
+	}
+	logger.Log(level, msg, attrs...)
+}
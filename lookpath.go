@@ -0,0 +1,211 @@
+package run
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	lookPathMaxLinks = 40 // Максимальное количество символических ссылок при разрешении пути в chroot.
+	lookPathEnv      = "PATH"
+)
+
+const (
+	errLookPathEmpty    = "не указано имя программы"
+	errLookPathDir      = "путь является директорией"
+	errLookPathExec     = "файл не является исполняемым"
+	errLookPathLinks    = "слишком много символических ссылок"
+	errLookPathRelative = "относительный путь в PATH пропущен"
+)
+
+// LookPathError Ошибка поиска программы с перечнем проверенных путей.
+type LookPathError struct {
+	Name     string   // Имя программы.
+	Chroot   string   // Директория chroot, в которой выполнялся поиск.
+	Searched []string // Проверенные пути в файловой системе процесса, то есть относительно директории chroot.
+	Err      error    // Причина неудачи последней проверки.
+}
+
+// Error Реализация интерфейса error.
+func (e *LookPathError) Error() (ret string) {
+	ret = fmt.Sprintf("программа %q не найдена", e.Name)
+	if e.Chroot != "" {
+		ret += fmt.Sprintf(" в chroot %q", e.Chroot)
+	}
+	if len(e.Searched) > 0 {
+		ret += ", проверены пути: " + strings.Join(e.Searched, ", ")
+	}
+	if e.Err != nil {
+		ret += ": " + e.Err.Error()
+	}
+
+	return
+}
+
+// Unwrap Исходная ошибка.
+func (e *LookPathError) Unwrap() error { return e.Err }
+
+// LookPath Поиск программы так, как её найдёт запускаемый процесс: по переменной PATH окружения процесса,
+// с учётом рабочей директории и директории chroot. Имя, содержащее "/", не ищется в PATH, а проверяется
+// относительно рабочей директории. Возвращается путь в файловой системе процесса.
+// При неудаче ошибка *LookPathError сохраняется и доступна через Error(), успешный поиск ошибку не изменяет.
+func (run *impl) LookPath(proc string) (ret string) {
+	var (
+		err         error
+		env         []string
+		dir, chroot string
+	)
+
+	run.fieldSync.RLock()
+	env, dir = run.envResolve(), run.attributes.Dir
+	if run.attributes.Sys != nil {
+		chroot = run.attributes.Sys.Chroot
+	}
+	run.fieldSync.RUnlock()
+	// Успешный поиск не сбрасывает ранее сохранённую ошибку настройки объекта.
+	if ret, err = lookPath(proc, env, dir, chroot); err != nil {
+		run.errSet(err)
+	}
+
+	return
+}
+
+// Поиск программы в файловой системе процесса. Если окружение равно nil, используется переменная PATH
+// текущего процесса. Относительные элементы PATH пропускаются, так как зависят от рабочей директории.
+func lookPath(name string, env []string, dir string, chroot string) (ret string, err error) {
+	var (
+		lpe       = &LookPathError{Name: name, Chroot: chroot}
+		path      string
+		ok        bool
+		candidate string
+	)
+
+	if name == "" {
+		lpe.Err = errors.New(errLookPathEmpty)
+		return "", lpe
+	}
+	if strings.Contains(name, "/") {
+		if candidate = name; !filepath.IsAbs(name) {
+			if candidate, err = lookPathBase(dir, chroot); err != nil {
+				lpe.Err = err
+				return "", lpe
+			}
+			candidate = filepath.Join(candidate, name)
+		}
+		lpe.Searched = append(lpe.Searched, candidate)
+		if lpe.Err = lookPathCheck(candidate, chroot); lpe.Err != nil {
+			return "", lpe
+		}
+		return candidate, nil
+	}
+	if env == nil {
+		path = os.Getenv(lookPathEnv)
+	} else if path, ok = newEnvTable(env).get(lookPathEnv); !ok {
+		path = ""
+	}
+	for _, item := range filepath.SplitList(path) {
+		if !filepath.IsAbs(item) {
+			lpe.Searched = append(lpe.Searched, fmt.Sprintf("%q (%s)", item, errLookPathRelative))
+			continue
+		}
+		candidate = filepath.Join(item, name)
+		lpe.Searched = append(lpe.Searched, candidate)
+		if lpe.Err = lookPathCheck(candidate, chroot); lpe.Err == nil {
+			return candidate, nil
+		}
+	}
+
+	return "", lpe
+}
+
+// Абсолютный путь рабочей директории процесса в файловой системе процесса.
+// В chroot относительная рабочая директория отсчитывается от корня chroot.
+func lookPathBase(dir string, chroot string) (ret string, err error) {
+	switch {
+	case filepath.IsAbs(dir):
+		ret = dir
+	case chroot != "":
+		ret = filepath.Join("/", dir)
+	case dir == "":
+		ret, err = os.Getwd()
+	default:
+		ret, err = filepath.Abs(dir)
+	}
+
+	return
+}
+
+// Проверка, что путь в файловой системе процесса указывает на исполняемый файл.
+func lookPathCheck(path string, chroot string) (err error) {
+	var (
+		host = path
+		fi   os.FileInfo
+	)
+
+	if chroot != "" {
+		if host, err = chrootResolve(chroot, path); err != nil {
+			return
+		}
+	}
+	if fi, err = os.Stat(host); err != nil {
+		return
+	}
+	switch {
+	case fi.IsDir():
+		err = errors.New(errLookPathDir)
+	case fi.Mode()&0111 == 0:
+		err = errors.New(errLookPathExec)
+	}
+
+	return
+}
+
+// Путь в файловой системе текущего процесса для пути внутри директории chroot.
+// Символические ссылки разрешаются относительно директории chroot, поэтому путь не выходит за её пределы.
+func chrootResolve(root string, path string) (ret string, err error) {
+	var (
+		parts  = strings.Split(path, "/")
+		cur    = "/"
+		next   string
+		target string
+		links  int
+		fi     os.FileInfo
+	)
+
+	for len(parts) > 0 {
+		part := parts[0]
+		parts = parts[1:]
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			cur = filepath.Dir(cur)
+			continue
+		}
+		next = filepath.Join(cur, part)
+		if fi, err = os.Lstat(filepath.Join(root, next)); err != nil {
+			return
+		}
+		if fi.Mode()&os.ModeSymlink == 0 {
+			cur = next
+			continue
+		}
+		if links++; links > lookPathMaxLinks {
+			err = errors.New(errLookPathLinks)
+			return
+		}
+		if target, err = os.Readlink(filepath.Join(root, next)); err != nil {
+			return
+		}
+		if filepath.IsAbs(target) {
+			cur = "/"
+		}
+		parts = append(strings.Split(target, "/"), parts...)
+	}
+	ret = filepath.Join(root, cur)
+
+	return
+}
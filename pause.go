@@ -0,0 +1,193 @@
+package run
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	cgroupProcs   = "cgroup.procs"  // Файл cgroup v2 со списком процессов группы.
+	cgroupFreeze  = "cgroup.freeze" // Файл cgroup v2 управления заморозкой группы.
+	cgroupEvents  = "cgroup.events" // Файл cgroup v2 с событиями группы.
+	cgroupFrozen  = "frozen"        // Ключ состояния заморозки в файле событий cgroup v2.
+	cgroupTimeout = time.Second     // Время ожидания заморозки или разморозки группы.
+)
+
+// ProcessGroup Запускаемое приложение выполняется в собственной группе процессов.
+// Сигналы приостановки и возобновления выполнения передаются всей группе процессов.
+func (run *impl) ProcessGroup(enable bool) Interface {
+	const msgGroup = "config.process_group"
+
+	run.fieldSync.Lock()
+	if run.attributes.Sys == nil {
+		run.attributes.Sys = new(syscall.SysProcAttr)
+	}
+	run.attributes.Sys.Setpgid = enable
+	run.fieldSync.Unlock()
+	run.log(LevelDebug, msgGroup, attr("enabled", enable))
+
+	return run
+}
+
+// Cgroup Запускаемое приложение помещается в указанную группу cgroup v2 при создании процесса, поэтому все его
+// потомки так же находятся в группе. На ядрах Linux до версии 5.7 процесс помещается в группу сразу после
+// запуска, и потомки, созданные до этого момента, могут оказаться вне группы.
+// Группа должна существовать и быть доступной для записи. Если группа указана, приостановка и возобновление
+// выполнения процесса выполняются через механизм заморозки cgroup v2.
+func (run *impl) Cgroup(path string) Interface {
+	const msgCgroup = "config.cgroup"
+
+	run.fieldSync.Lock()
+	run.cgroupPath = path
+	run.fieldSync.Unlock()
+	run.log(LevelDebug, msgCgroup, attr("path", path))
+
+	return run
+}
+
+// Помещение процесса в группу cgroup v2.
+func cgroupAttach(path string, pid int) (err error) {
+	const errCgroup = "помещение процесса %d в группу cgroup %q прервано ошибкой: %s"
+
+	if err = os.WriteFile(filepath.Join(path, cgroupProcs), []byte(strconv.Itoa(pid)), 0); err != nil {
+		err = fmt.Errorf(errCgroup, pid, path, err)
+	}
+
+	return
+}
+
+// Заморозка или разморозка группы cgroup v2 с ожиданием завершения операции.
+func cgroupSetFrozen(path string, frozen bool) (err error) {
+	const (
+		errFreeze  = "изменение состояния заморозки группы cgroup %q прервано ошибкой: %s"
+		errTimeout = "истекло время ожидания изменения состояния заморозки группы cgroup %q"
+	)
+	var (
+		value    = []byte{'0'}
+		expected = []byte(cgroupFrozen + " 0")
+		deadline time.Time
+		buf      []byte
+	)
+
+	if frozen {
+		value, expected = []byte{'1'}, []byte(cgroupFrozen+" 1")
+	}
+	if err = os.WriteFile(filepath.Join(path, cgroupFreeze), value, 0); err != nil {
+		err = fmt.Errorf(errFreeze, path, err)
+		return
+	}
+	for deadline = time.Now().Add(cgroupTimeout); time.Now().Before(deadline); {
+		if buf, err = os.ReadFile(filepath.Join(path, cgroupEvents)); err != nil {
+			err = fmt.Errorf(errFreeze, path, err)
+			return
+		}
+		if bytes.Contains(buf, expected) {
+			return
+		}
+		<-time.After(cgroupTimeout / 100)
+	}
+	err = fmt.Errorf(errTimeout, path)
+
+	return
+}
+
+// Отправка сигнала процессу или группе процессов, если процесс запущен в собственной группе.
+func (run *impl) signalGroup(sig syscall.Signal) (err error) {
+	const errRun = "процесс не запущен"
+	var pid, target int
+
+	run.fieldSync.RLock()
+	if run.process != nil {
+		pid, target = run.process.Pid, run.process.Pid
+	}
+	if run.attributes.Sys != nil && run.attributes.Sys.Setpgid {
+		target = -pid
+	}
+	run.fieldSync.RUnlock()
+	if pid <= 0 {
+		return errors.New(errRun)
+	}
+	if err = syscall.Kill(target, sig); err == nil {
+		run.fire(Event{Type: EventSignalSent, Pid: pid, Args: run.Command(), Signal: sig})
+	}
+
+	return
+}
+
+// Признак сигнала, завершающего процесс, который приостановленный процесс не обработает до возобновления
+// выполнения. Сигнал SIGKILL завершает и приостановленный процесс, поэтому не учитывается.
+func signalTerminates(sig os.Signal) bool {
+	switch sig {
+	case syscall.SIGHUP, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGABRT, syscall.SIGTERM:
+		return true
+	default:
+		return false
+	}
+}
+
+// Путь к группе cgroup v2, в которую помещается процесс.
+func (run *impl) cgroupGet() (ret string) {
+	run.fieldSync.RLock()
+	ret = run.cgroupPath
+	run.fieldSync.RUnlock()
+
+	return
+}
+
+// Pause Приостановка выполнения запущенного приложения.
+// Если указана группа cgroup, приложение замораживается через cgroup v2, иначе приложению или его группе
+// процессов отправляется сигнал SIGSTOP.
+func (run *impl) Pause() (err error) {
+	const (
+		opPause  = "Pause"
+		msgPause = "process.pause"
+	)
+	var cgroupPath string
+
+	if err = run.stateIs(opPause, StateRunning); err != nil {
+		return
+	}
+	cgroupPath = run.cgroupGet()
+	run.log(LevelInfo, msgPause, attr("pid", run.Pid()), attr("cgroup", cgroupPath))
+	if cgroupPath != "" {
+		err = cgroupSetFrozen(cgroupPath, true)
+	} else {
+		err = run.signalGroup(syscall.SIGSTOP)
+	}
+	if err == nil {
+		err = run.stateSet(opPause, StatePaused)
+	}
+
+	return
+}
+
+// Resume Возобновление выполнения приостановленного приложения.
+func (run *impl) Resume() (err error) {
+	const (
+		opResume  = "Resume"
+		msgResume = "process.resume"
+	)
+	var cgroupPath string
+
+	if err = run.stateIs(opResume, StatePaused); err != nil {
+		return
+	}
+	cgroupPath = run.cgroupGet()
+	run.log(LevelInfo, msgResume, attr("pid", run.Pid()), attr("cgroup", cgroupPath))
+	if cgroupPath != "" {
+		err = cgroupSetFrozen(cgroupPath, false)
+	} else {
+		err = run.signalGroup(syscall.SIGCONT)
+	}
+	if err == nil {
+		err = run.stateSet(opResume, StateRunning)
+	}
+
+	return
+}
@@ -0,0 +1,40 @@
+package run
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// Сигнал завершения, отправленный приостановленному процессу, обрабатывается процессом без вызова Resume().
+// Сигнал без обработчика ядро доставляет и остановленному процессу, поэтому процесс устанавливает обработчик.
+func TestPauseSignal(t *testing.T) {
+	for _, group := range []bool{false, true} {
+		var run = New().ProcessGroup(group)
+
+		if err := run.Run(context.Background(), "sh", "-c", "trap 'exit 7' TERM; while :; do sleep 0.1; done").Error(); err != nil {
+			t.Fatalf("запуск процесса прерван ошибкой: %v", err)
+		}
+		// Ожидание установки обработчика сигнала.
+		time.Sleep(200 * time.Millisecond)
+		if err := run.Pause(); err != nil {
+			t.Fatalf("приостановка процесса прервана ошибкой: %v", err)
+		}
+		if state := run.State(); state != StatePaused {
+			t.Errorf("состояние процесса %s, ожидается %s", state, StatePaused)
+		}
+		if err := run.Signal(syscall.SIGTERM); err != nil {
+			t.Errorf("отправка сигнала прервана ошибкой: %v", err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := run.WaitState(ctx, StateExited); err != nil {
+			_ = run.Kill()
+			t.Fatalf("группа процессов %t: сигнал SIGTERM не обработан: %v", group, err)
+		}
+		cancel()
+		if res := run.Result(); res == nil || res.ExitCode != 7 {
+			t.Errorf("группа процессов %t: результат %+v, ожидается код завершения обработчика сигнала", group, res)
+		}
+	}
+}
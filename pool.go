@@ -0,0 +1,20 @@
+package run
+
+import "sync"
+
+// Пул буферов чтения и записи потоков, исключающий выделение памяти при каждом запуске процесса.
+var bufPool = sync.Pool{New: func() any { return new([]byte) }}
+
+// Получение буфера размером size байт из пула.
+func bufferGet(size int) (ret *[]byte) {
+	ret = bufPool.Get().(*[]byte)
+	if cap(*ret) < size {
+		*ret = make([]byte, size)
+	}
+	*ret = (*ret)[:size]
+
+	return
+}
+
+// Возвращение буфера в пул.
+func bufferPut(buf *[]byte) { bufPool.Put(buf) }
@@ -0,0 +1,391 @@
+//go:build linux
+
+package run
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+const (
+	procRoot       = "/proc" // Точка монтирования файловой системы proc.
+	procClockTicks = 100     // Значение USER_HZ, используемое ядром для времени в файлах /proc.
+	procPollPeriod = 100 * time.Millisecond
+)
+
+// Данные процесса, полученные из файла /proc/<pid>/stat.
+type procStat struct {
+	Pid        int    // Идентификатор процесса.
+	Comm       string // Имя исполняемого файла.
+	State      byte   // Состояние процесса.
+	PPid       int    // Идентификатор родительского процесса.
+	PGrp       int    // Идентификатор группы процессов.
+	MinFlt     uint64 // Количество незначительных ошибок страниц.
+	MajFlt     uint64 // Количество значительных ошибок страниц.
+	UTime      uint64 // Время выполнения в режиме пользователя, в тиках.
+	STime      uint64 // Время выполнения в режиме ядра, в тиках.
+	Nice       int    // Значение nice.
+	Threads    int    // Количество потоков.
+	StartTicks uint64 // Время запуска процесса после загрузки системы, в тиках.
+	VSize      uint64 // Размер виртуальной памяти в байтах.
+	RSSPages   uint64 // Размер резидентной памяти в страницах.
+}
+
+// Путь к файлу или директории процесса в файловой системе proc.
+func procPath(pid int, name ...string) string {
+	return filepath.Join(append([]string{procRoot, strconv.Itoa(pid)}, name...)...)
+}
+
+// Чтение и разбор файла /proc/<pid>/stat.
+func procReadStat(pid int) (ret *procStat, err error) {
+	const (
+		errRead   = "чтение статуса процесса %d прервано ошибкой: %s"
+		errFormat = "не верный формат файла статуса процесса %d"
+	)
+	var (
+		buf    []byte
+		beg    int
+		end    int
+		fields []string
+	)
+
+	if buf, err = os.ReadFile(procPath(pid, "stat")); err != nil {
+		err = fmt.Errorf(errRead, pid, err)
+		return
+	}
+	// Имя процесса может содержать пробелы и скобки, поэтому поиск ведётся по последней закрывающей скобке.
+	beg, end = bytes.IndexByte(buf, '('), bytes.LastIndexByte(buf, ')')
+	if beg < 0 || end < beg {
+		err = fmt.Errorf(errFormat, pid)
+		return
+	}
+	if fields = strings.Fields(string(buf[end+1:])); len(fields) < 22 || len(fields[0]) == 0 {
+		err = fmt.Errorf(errFormat, pid)
+		return
+	}
+	ret = &procStat{
+		Pid:        pid,
+		Comm:       string(buf[beg+1 : end]),
+		State:      fields[0][0],
+		PPid:       atoi(fields[1]),
+		PGrp:       atoi(fields[2]),
+		MinFlt:     atou(fields[7]),
+		MajFlt:     atou(fields[9]),
+		UTime:      atou(fields[11]),
+		STime:      atou(fields[12]),
+		Nice:       atoi(fields[16]),
+		Threads:    atoi(fields[17]),
+		StartTicks: atou(fields[19]),
+		VSize:      atou(fields[20]),
+		RSSPages:   atou(fields[21]),
+	}
+
+	return
+}
+
+// Чтение файла процесса, содержащего строки разделённые нулевым байтом.
+func procReadNullSeparated(pid int, name string) (ret []string, err error) {
+	var buf []byte
+
+	if buf, err = os.ReadFile(procPath(pid, name)); err != nil {
+		return
+	}
+	buf = bytes.TrimRight(buf, "\x00")
+	if len(buf) == 0 {
+		return
+	}
+	ret = strings.Split(string(buf), "\x00")
+
+	return
+}
+
+// Время загрузки системы.
+func procBootTime() (ret time.Time, err error) {
+	const (
+		keyBtime = "btime"
+		errBtime = "время загрузки системы не найдено"
+	)
+	var buf []byte
+
+	if buf, err = os.ReadFile(filepath.Join(procRoot, "stat")); err != nil {
+		return
+	}
+	for _, line := range strings.Split(string(buf), "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 && fields[0] == keyBtime {
+			ret = time.Unix(int64(atou(fields[1])), 0)
+			return
+		}
+	}
+	err = fmt.Errorf(errBtime)
+
+	return
+}
+
+// Преобразование времени в тиках в продолжительность.
+func ticksToDuration(ticks uint64) time.Duration {
+	return time.Duration(ticks) * time.Second / procClockTicks
+}
+
+// Получение информации о процессе из файловой системы proc.
+func procInfo(pid int) (ret *ProcessInfo, err error) {
+	var (
+		st   *procStat
+		boot time.Time
+	)
+
+	if st, err = procReadStat(pid); err != nil {
+		return
+	}
+	ret = &ProcessInfo{Pid: pid, PPid: st.PPid, Name: st.Comm, startTicks: st.StartTicks}
+	if boot, err = procBootTime(); err == nil {
+		ret.StartTime = boot.Add(ticksToDuration(st.StartTicks))
+	}
+	// Командная строка, директория и окружение могут быть недоступны без соответствующих прав, это не ошибка.
+	ret.Cmdline, _ = procReadNullSeparated(pid, "cmdline")
+	ret.Env, _ = procReadNullSeparated(pid, "environ")
+	ret.Cwd, _ = os.Readlink(procPath(pid, "cwd"))
+	err = nil
+
+	return
+}
+
+// Время запуска процесса в тиках, используется для проверки того, что PID не был переиспользован.
+func procStartTicks(pid int) (ret uint64, alive bool, err error) {
+	var st *procStat
+
+	if _, err = os.Stat(procPath(pid)); os.IsNotExist(err) {
+		err = nil
+		return
+	}
+	if st, err = procReadStat(pid); err != nil {
+		return
+	}
+	ret, alive = st.StartTicks, st.State != 'Z' && st.State != 'X'
+
+	return
+}
+
+// Открытие файлового дескриптора процесса через pidfd_open.
+func pidfdOpen(pid int) (ret int, err error) {
+	var (
+		fd    uintptr
+		errno syscall.Errno
+	)
+
+	if fd, _, errno = syscall.Syscall(sysPidfdOpen, uintptr(pid), 0, 0); errno != 0 {
+		ret, err = -1, errno
+		return
+	}
+	ret = int(fd)
+
+	return
+}
+
+// Отправка сигнала процессу через его файловый дескриптор, исключающая ошибку переиспользования PID.
+func pidfdSendSignal(fd int, sig syscall.Signal) (err error) {
+	var errno syscall.Errno
+
+	if _, _, errno = syscall.Syscall6(sysPidfdSignal, uintptr(fd), uintptr(sig), 0, 0, 0, 0); errno != 0 {
+		err = errno
+	}
+
+	return
+}
+
+// Ожидание готовности файлового дескриптора процесса к чтению, что означает завершение процесса.
+// Возвращается истина, если процесс завершился до истечения времени ожидания.
+func pidfdWait(fd int, timeout time.Duration) (ret bool, err error) {
+	var (
+		epfd   int
+		n      int
+		events [1]syscall.EpollEvent
+	)
+
+	if epfd, err = syscall.EpollCreate1(syscall.EPOLL_CLOEXEC); err != nil {
+		return
+	}
+	defer func() { _ = syscall.Close(epfd) }()
+	if err = syscall.EpollCtl(epfd, syscall.EPOLL_CTL_ADD, fd, &syscall.EpollEvent{
+		Events: syscall.EPOLLIN,
+		Fd:     int32(fd),
+	}); err != nil {
+		return
+	}
+	for {
+		if n, err = syscall.EpollWait(epfd, events[:], int(timeout/time.Millisecond)); err == syscall.EINTR {
+			continue
+		}
+		break
+	}
+	ret = err == nil && n > 0
+
+	return
+}
+
+// Копия файлового дескриптора процесса с флагом close-on-exec, которая закрывается независимо от исходного.
+func pidfdDup(fd int) (ret int, err error) {
+	var (
+		nfd   uintptr
+		errno syscall.Errno
+	)
+
+	if nfd, _, errno = syscall.Syscall(syscall.SYS_FCNTL, uintptr(fd), syscall.F_DUPFD_CLOEXEC, 0); errno != 0 {
+		ret, err = -1, errno
+		return
+	}
+	ret = int(nfd)
+
+	return
+}
+
+// Закрытие файлового дескриптора процесса.
+func pidfdClose(fd int) error { return syscall.Close(fd) }
+
+// Ожидание завершения процесса без освобождения его записи в таблице процессов. До вызова os.Process.Wait()
+// процесс остаётся в состоянии зомби, поэтому его PID не может быть занят другим процессом.
+func processWaitExit(pid int) (err error) {
+	const (
+		idPid   = 1 // P_PID
+		wExited = 4 // WEXITED
+	)
+	var (
+		info  [128]byte // siginfo_t
+		errno syscall.Errno
+	)
+
+	for {
+		if _, _, errno = syscall.Syscall6(
+			syscall.SYS_WAITID, idPid, uintptr(pid), uintptr(unsafe.Pointer(&info[0])), wExited|syscall.WNOWAIT, 0, 0,
+		); errno != syscall.EINTR {
+			break
+		}
+	}
+	if errno != 0 {
+		err = errno
+	}
+
+	return
+}
+
+func atoi(s string) (ret int) { ret, _ = strconv.Atoi(s); return }
+
+func atou(s string) (ret uint64) { ret, _ = strconv.ParseUint(s, 10, 64); return }
+
+// Чтение файла процесса, содержащего строки вида "ключ: значение".
+func procReadKeyValue(pid int, name string) (ret map[string]string, err error) {
+	var buf []byte
+
+	if buf, err = os.ReadFile(procPath(pid, name)); err != nil {
+		return
+	}
+	ret = make(map[string]string)
+	for _, line := range strings.Split(string(buf), "\n") {
+		if n := strings.IndexByte(line, ':'); n > 0 {
+			ret[strings.TrimSpace(line[:n])] = strings.TrimSpace(line[n+1:])
+		}
+	}
+
+	return
+}
+
+// Преобразование значения вида "1024 kB" в байты.
+func procKilobytes(s string) uint64 { return atou(strings.TrimSuffix(s, " kB")) * 1024 }
+
+// Получение статистики процесса из файлов /proc/<pid>/{stat,status,io,fd}.
+func procStats(pid int) (ret *Stats, err error) {
+	var (
+		st     *procStat
+		status map[string]string
+		io     map[string]string
+		fds    []os.DirEntry
+	)
+
+	if st, err = procReadStat(pid); err != nil {
+		return
+	}
+	ret = &Stats{
+		Time:      time.Now(),
+		Pid:       pid,
+		State:     string(st.State),
+		CPUUser:   ticksToDuration(st.UTime),
+		CPUSystem: ticksToDuration(st.STime),
+		Threads:   st.Threads,
+		RSS:       st.RSSPages * uint64(os.Getpagesize()),
+	}
+	if status, err = procReadKeyValue(pid, "status"); err == nil {
+		ret.RSS, ret.VMPeak = procKilobytes(status["VmRSS"]), procKilobytes(status["VmPeak"])
+	}
+	// Файлы io и fd доступны только владельцу процесса, при отсутствии прав значения остаются нулевыми.
+	if io, err = procReadKeyValue(pid, "io"); err == nil {
+		ret.ReadBytes, ret.WriteBytes = atou(io["read_bytes"]), atou(io["write_bytes"])
+	}
+	if fds, err = os.ReadDir(procPath(pid, "fd")); err == nil {
+		ret.FDs = len(fds)
+	}
+	err = nil
+
+	return
+}
+
+// Поиск всех потомков процесса, в порядке обхода дерева процессов в ширину.
+func procDescendants(pid int) (ret []int, err error) {
+	var (
+		entries  []os.DirEntry
+		children map[int][]int
+		queue    []int
+	)
+
+	if entries, err = os.ReadDir(procRoot); err != nil {
+		return
+	}
+	children = make(map[int][]int)
+	for _, entry := range entries {
+		var (
+			child int
+			st    *procStat
+		)
+		if child = atoi(entry.Name()); child <= 0 || !entry.IsDir() {
+			continue
+		}
+		if st, err = procReadStat(child); err != nil {
+			// Процесс мог завершиться во время обхода.
+			err = nil
+			continue
+		}
+		children[st.PPid] = append(children[st.PPid], child)
+	}
+	for queue = children[pid]; len(queue) > 0; queue = queue[1:] {
+		ret = append(ret, queue[0])
+		queue = append(queue, children[queue[0]]...)
+	}
+
+	return
+}
+
+// Настройка запуска процесса сразу в группе cgroup v2, открытой как директория fh, системным вызовом clone3
+// с флагом CLONE_INTO_CGROUP. Значение nil отключает запуск в группе. Возвращается истина, если запуск
+// в группе поддерживается.
+func cgroupClone(attr *os.ProcAttr, fh *os.File) bool {
+	if attr.Sys == nil {
+		attr.Sys = new(syscall.SysProcAttr)
+	}
+	attr.Sys.UseCgroupFD, attr.Sys.CgroupFD = false, 0
+	if fh != nil {
+		attr.Sys.UseCgroupFD, attr.Sys.CgroupFD = true, int(fh.Fd())
+	}
+
+	return true
+}
+
+// Ошибка запуска означает, что ядро не поддерживает запуск процесса в группе cgroup, ядра до версии 5.7.
+func cgroupCloneUnsupported(err error) bool {
+	return errors.Is(err, syscall.ENOSYS) || errors.Is(err, syscall.E2BIG)
+}
@@ -0,0 +1,57 @@
+//go:build !linux
+
+package run
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"time"
+)
+
+const (
+	procPollPeriod = 100 * time.Millisecond
+	errUnsupported = "функция не поддерживается на данной операционной системе"
+)
+
+// Получение информации о процессе из файловой системы proc.
+func procInfo(_ int) (ret *ProcessInfo, err error) { err = errors.New(errUnsupported); return }
+
+// Время запуска процесса в тиках, используется для проверки того, что PID не был переиспользован.
+func procStartTicks(_ int) (ret uint64, alive bool, err error) {
+	err = errors.New(errUnsupported)
+	return
+}
+
+// Открытие файлового дескриптора процесса через pidfd_open.
+func pidfdOpen(_ int) (ret int, err error) { ret, err = -1, errors.New(errUnsupported); return }
+
+// Отправка сигнала процессу через его файловый дескриптор.
+func pidfdSendSignal(_ int, _ syscall.Signal) error { return errors.New(errUnsupported) }
+
+// Ожидание завершения процесса через его файловый дескриптор.
+func pidfdWait(_ int, _ time.Duration) (ret bool, err error) {
+	err = errors.New(errUnsupported)
+	return
+}
+
+// Копия файлового дескриптора процесса.
+func pidfdDup(_ int) (ret int, err error) { ret, err = -1, errors.New(errUnsupported); return }
+
+// Закрытие файлового дескриптора процесса.
+func pidfdClose(_ int) error { return errors.New(errUnsupported) }
+
+// Ожидание завершения процесса без освобождения его записи в таблице процессов.
+func processWaitExit(_ int) error { return errors.New(errUnsupported) }
+
+// Получение статистики процесса из файловой системы proc.
+func procStats(_ int) (ret *Stats, err error) { err = errors.New(errUnsupported); return }
+
+// Поиск всех потомков процесса.
+func procDescendants(_ int) (ret []int, err error) { err = errors.New(errUnsupported); return }
+
+// Запуск процесса сразу в группе cgroup v2 не поддерживается.
+func cgroupClone(_ *os.ProcAttr, _ *os.File) bool { return false }
+
+// Запуск процесса сразу в группе cgroup v2 не поддерживается.
+func cgroupCloneUnsupported(_ error) bool { return false }
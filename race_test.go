@@ -0,0 +1,235 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// Продолжительность нагрузочных тестов конкурентного доступа.
+const testRaceDuration = 2 * time.Second
+
+// Выполнение функции в цикле в отдельной горутине до закрытия канала stop.
+func testLoop(wg *sync.WaitGroup, stop <-chan struct{}, fn func()) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				fn()
+			}
+		}
+	}()
+}
+
+// Ожидание завершения горутин теста с ограничением времени, зависание считается ошибкой.
+func testWait(t *testing.T, wg *sync.WaitGroup, timeout time.Duration) {
+	var done = make(chan struct{})
+
+	go func() { wg.Wait(); close(done) }()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		t.Fatalf("горутины теста не завершились за %s", timeout)
+	}
+}
+
+// Одновременный вызов функций управления, состояния, статистики, подписки и настройки журнала одного объекта
+// во время запуска, завершения и сброса процессов. Функции Run(), Wait(), Signal() и Reset() вызываются из
+// разных горутин, поэтому ожидание процесса выполняется одновременно с его сбросом.
+func TestRaceSingleObject(t *testing.T) {
+	var (
+		run     = New()
+		wg      sync.WaitGroup
+		stop    = make(chan struct{})
+		logged  atomic.Int64
+		started atomic.Int64
+		waited  atomic.Int64
+		logger  = LoggerFunc(func(Level, string, ...Attr) { logged.Add(1) })
+	)
+
+	// Запуск процессов.
+	testLoop(&wg, stop, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+		if run.Run(ctx, "sh", "-c", "while :; do echo line; sleep 0.01; done").Error() == nil {
+			started.Add(1)
+		}
+		_ = run.WaitState(ctx, StateExited)
+	})
+	// Ожидание завершения процесса возвращает состояние процесса или ошибку *StateError.
+	testLoop(&wg, stop, func() {
+		var se *StateError
+
+		ret, err := run.Wait()
+		switch {
+		case ret != nil:
+			waited.Add(1)
+		case errors.As(err, &se):
+			time.Sleep(time.Millisecond)
+		default:
+			t.Errorf("Wait() вернула состояние nil и ошибку %v, ожидается *StateError", err)
+		}
+	})
+	// Сброс объекта, в том числе во время выполнения и ожидания процесса.
+	testLoop(&wg, stop, func() {
+		time.Sleep(time.Duration(rand.Intn(100)) * time.Millisecond)
+		run.Reset()
+	})
+	// Управление процессом.
+	testLoop(&wg, stop, func() {
+		_ = run.Signal(syscall.SIGTERM)
+		time.Sleep(time.Duration(rand.Intn(50)) * time.Millisecond)
+	})
+	testLoop(&wg, stop, func() {
+		_ = run.Pause()
+		time.Sleep(time.Millisecond)
+		_ = run.Resume()
+	})
+	testLoop(&wg, stop, func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = run.Kill()
+	})
+	// PID равен -1 или принадлежит существующему процессу. Процесс может завершиться после получения PID,
+	// тогда функция Pid() возвращает уже другое значение.
+	testLoop(&wg, stop, func() {
+		if pid := run.Pid(); pid != -1 && (pid <= 0 || syscall.Kill(pid, 0) != nil && run.Pid() == pid) {
+			t.Errorf("Pid() вернула %d, процесс не существует", pid)
+		}
+	})
+	// Состояние, статистика и результат.
+	testLoop(&wg, stop, func() {
+		_ = run.State()
+		_, _ = run.Stats(true)
+		_ = run.Result()
+		_ = run.Usage()
+		_ = run.Command()
+		_ = run.Error()
+		_ = run.StdOut()
+		time.Sleep(time.Millisecond)
+	})
+	testLoop(&wg, stop, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		_ = run.WaitState(ctx, StateRunning)
+		for range run.StatsCh(ctx, 5*time.Millisecond, false) {
+		}
+	})
+	// Подписчики с разными политиками, в том числе не читающий канал.
+	testLoop(&wg, stop, func() {
+		sub := run.StdOutSubscribe(&SubscribeOptions{Replay: true, Buffer: 1, Policy: PolicyBlock})
+		timer := time.NewTimer(30 * time.Millisecond)
+		defer timer.Stop()
+		for {
+			select {
+			case _, ok := <-sub.Ch():
+				if !ok {
+					return
+				}
+			case <-timer.C:
+				sub.Close()
+				return
+			}
+		}
+	})
+	testLoop(&wg, stop, func() {
+		_ = run.StdOutSubscribe(&SubscribeOptions{Buffer: 1, Policy: PolicyDrop})
+		_ = run.StdErrSubscribe(&SubscribeOptions{Buffer: 1, Policy: PolicyDisconnect})
+		time.Sleep(10 * time.Millisecond)
+	})
+	// Настройка журнала и событий.
+	testLoop(&wg, stop, func() {
+		run.Logger(NewLogger(io.Discard, LevelDebug))
+		run.Debug(false)
+		run.Logger(logger)
+		run.OnEvent(func(Event) {})
+		time.Sleep(time.Millisecond)
+	})
+	time.Sleep(testRaceDuration)
+	close(stop)
+	testWait(t, &wg, 30*time.Second)
+	run.Reset()
+	if started.Load() == 0 || waited.Load() == 0 {
+		t.Errorf("запущено процессов %d, дождались завершения %d, ожидается не меньше одного",
+			started.Load(), waited.Load())
+	}
+	t.Logf("запущено процессов: %d, дождались завершения: %d, сообщений журнала: %d",
+		started.Load(), waited.Load(), logged.Load())
+}
+
+// Одновременное выполнение множества объектов с подписчиками и событиями.
+func TestRaceManyObjects(t *testing.T) {
+	const (
+		count  = 16
+		script = "i=0; while [ $i -lt 100 ]; do echo $i; i=$((i+1)); done"
+	)
+	var wg sync.WaitGroup
+
+	for n := 0; n < count; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			var (
+				run    = New()
+				events = run.Events(ctx)
+				out    = run.StdOutCh()
+				read   = make(chan int)
+				total  int
+			)
+
+			go func() {
+				for range events {
+				}
+			}()
+			// Подписчик с политикой PolicyBlock получает все данные, поэтому канал читается во время выполнения.
+			go func() {
+				var n int
+				for data := range out {
+					n += len(data)
+				}
+				read <- n
+			}()
+			if _, err := run.RunWait(ctx, "sh", "-c", script); err != nil {
+				t.Errorf("ошибка выполнения процесса: %v", err)
+				return
+			}
+			total = <-read
+			if total != len(run.StdOut()) {
+				t.Errorf("подписчик получил %d байт, сохранено %d байт", total, len(run.StdOut()))
+			}
+		}()
+	}
+	testWait(t, &wg, 30*time.Second)
+}
+
+// Подписчик, не читающий канал, не препятствует завершению ожидания процесса после прерывания через контекст.
+func TestStuckSubscriber(t *testing.T) {
+	var (
+		run  = New()
+		done = make(chan struct{})
+	)
+
+	_ = run.StdOutCh()
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	go func() {
+		defer close(done)
+		_, _ = run.RunWait(ctx, "yes")
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("ожидание процесса зависло из-за подписчика, не читающего канал")
+	}
+}
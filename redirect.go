@@ -0,0 +1,122 @@
+package run
+
+import (
+	"fmt"
+	"os"
+)
+
+const (
+	redirectInp = iota // Индекс потока STDIN.
+	redirectOut        // Индекс потока STDOUT.
+	redirectErr        // Индекс потока STDERR.
+)
+
+// Перенаправление стандартного потока процесса в файл.
+type redirect struct {
+	path   string      // Путь к файлу.
+	flags  int         // Флаги открытия файла.
+	perm   os.FileMode // Права доступа создаваемого файла.
+	stdout bool        // Перенаправление потока STDERR в поток STDOUT.
+}
+
+// Установка перенаправления потока.
+func (run *impl) redirectSet(n int, rdr *redirect, stream string) Interface {
+	const msgRedirect = "config.redirect"
+
+	run.fieldSync.Lock()
+	run.redirects[n] = rdr
+	run.fieldSync.Unlock()
+	if rdr.stdout {
+		run.log(LevelDebug, msgRedirect, attr("stream", stream), attr("to", "stdout"))
+	} else {
+		run.log(LevelDebug, msgRedirect, attr("stream", stream), attr("path", rdr.path), attr("flags", rdr.flags))
+	}
+
+	return run
+}
+
+// StdInFile Процесс получает данные потока STDIN из указанного файла напрямую, без трубы и горутин пакета.
+// Данные, переданные через StdIn() и StdInCh(), процессу не передаются.
+func (run *impl) StdInFile(path string) Interface {
+	return run.redirectSet(redirectInp, &redirect{path: path, flags: os.O_RDONLY}, "stdin")
+}
+
+// StdOutFile Процесс записывает данные потока STDOUT в указанный файл напрямую, без трубы и горутин пакета.
+// Если флаги не указаны, файл открывается с флагами os.O_WRONLY|os.O_CREATE|os.O_TRUNC.
+// Данные потока не сохраняются и не передаются подписчикам и получателю StdOutWriter().
+func (run *impl) StdOutFile(path string, flags int, perm os.FileMode) Interface {
+	if flags == 0 {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	return run.redirectSet(redirectOut, &redirect{path: path, flags: flags, perm: perm}, "stdout")
+}
+
+// StdOutNull Данные потока STDOUT процесса отбрасываются.
+func (run *impl) StdOutNull() Interface {
+	return run.redirectSet(redirectOut, &redirect{path: os.DevNull, flags: os.O_WRONLY}, "stdout")
+}
+
+// StdErrFile Процесс записывает данные потока STDERR в указанный файл напрямую, без трубы и горутин пакета.
+// Если флаги не указаны, файл открывается с флагами os.O_WRONLY|os.O_CREATE|os.O_TRUNC.
+// Данные потока не сохраняются и не передаются подписчикам и получателю StdErrWriter().
+func (run *impl) StdErrFile(path string, flags int, perm os.FileMode) Interface {
+	if flags == 0 {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	return run.redirectSet(redirectErr, &redirect{path: path, flags: flags, perm: perm}, "stderr")
+}
+
+// StdErrNull Данные потока STDERR процесса отбрасываются.
+func (run *impl) StdErrNull() Interface {
+	return run.redirectSet(redirectErr, &redirect{path: os.DevNull, flags: os.O_WRONLY}, "stderr")
+}
+
+// StdErrToStdOut Поток STDERR процесса перенаправляется в поток STDOUT, аналогично "2>&1".
+// Данные обоих потоков поступают в поток STDOUT, в том числе в файл, указанный через StdOutFile().
+func (run *impl) StdErrToStdOut() Interface {
+	return run.redirectSet(redirectErr, &redirect{stdout: true}, "stderr")
+}
+
+// Открытие файлов перенаправления потоков и замена ими труб в атрибутах запуска процесса.
+// Возвращаются открытые файлы, которые необходимо закрыть после запуска процесса, и признаки перенаправления
+// каждого из потоков. Трубы перенаправленных потоков закрываются.
+func (run *impl) redirectApply() (opened []*os.File, redirected [3]bool, err error) {
+	const errOpen = "открытие файла %q для перенаправления потока прервано ошибкой: %s"
+	var (
+		fh    *os.File
+		pipes [3][2]*os.File
+	)
+
+	run.fieldSync.Lock()
+	defer run.fieldSync.Unlock()
+	for n, rdr := range run.redirects {
+		if rdr == nil || rdr.stdout {
+			continue
+		}
+		if fh, err = os.OpenFile(rdr.path, rdr.flags, rdr.perm); err != nil {
+			for _, fh = range opened {
+				_ = fh.Close()
+			}
+			opened, err = nil, fmt.Errorf(errOpen, rdr.path, err)
+			return
+		}
+		opened, redirected[n] = append(opened, fh), true
+		run.attributes.Files[n] = fh
+	}
+	if rdr := run.redirects[redirectErr]; rdr != nil && rdr.stdout {
+		run.attributes.Files[redirectErr], redirected[redirectErr] = run.attributes.Files[redirectOut], true
+	}
+	// Трубы перенаправленных потоков не используются.
+	pipes = [3][2]*os.File{
+		{run.pipeInpReader, run.pipeInpWriter},
+		{run.pipeOutReader, run.pipeOutWriter},
+		{run.pipeErrReader, run.pipeErrWriter},
+	}
+	for n := range redirected {
+		if redirected[n] {
+			_, _ = pipes[n][0].Close(), pipes[n][1].Close()
+		}
+	}
+
+	return
+}
@@ -0,0 +1,367 @@
+package run
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	rotatePerm       = 0640
+	rotateTimeFormat = "20060102T150405.000000000"
+	rotateGzip       = ".gz"
+	rotateTemp       = ".tmp"
+	rotateRetry      = time.Second // Время до повторной попытки ротации после неудачной ротации при записи.
+)
+
+// Файл с ротацией.
+type rotateWriter struct {
+	sync     *sync.Mutex     // Контроль монопольного доступа к файлу.
+	path     string          // Путь к файлу.
+	opt      RotateOptions   // Настройки.
+	file     *os.File        // Открытый файл.
+	size     int64           // Размер открытого файла.
+	opened   time.Time       // Время открытия файла, от которого отсчитывается интервал ротации.
+	retry    time.Time       // Время, до которого ротация при записи не выполняется после неудачной ротации.
+	err      error           // Ошибка последней ротации при записи.
+	plain    *rotateStream   // Получатель данных без названия потока.
+	compress *sync.WaitGroup // Сжатие ротированных файлов.
+	signalCh chan os.Signal  // Канал сигнала повторного открытия файла.
+	done     chan struct{}   // Канал, закрываемый при закрытии файла.
+	closed   bool            // Файл закрыт.
+}
+
+// Получатель данных потока, записывающий данные в файл с ротацией.
+type rotateStream struct {
+	rw        *rotateWriter // Файл с ротацией.
+	name      string        // Название потока.
+	lineStart bool          // Следующие данные начинают новую строку, изменяется под блокировкой файла.
+}
+
+// NewRotateWriter Конструктор файла с ротацией. Если настройки не указаны, ротация не выполняется.
+func NewRotateWriter(path string, opt *RotateOptions) (ret RotateWriter, err error) {
+	var rw *rotateWriter
+
+	rw = &rotateWriter{
+		sync:     new(sync.Mutex),
+		path:     path,
+		compress: new(sync.WaitGroup),
+		done:     make(chan struct{}),
+	}
+	if opt != nil {
+		rw.opt = *opt
+	}
+	if rw.opt.Perm == 0 {
+		rw.opt.Perm = rotatePerm
+	}
+	if rw.opt.TimeFormat == "" {
+		rw.opt.TimeFormat = time.RFC3339Nano
+	}
+	rw.plain = &rotateStream{rw: rw, lineStart: true}
+	if err = rw.open(); err != nil {
+		return
+	}
+	if rw.opt.Reopen {
+		rw.signalCh = make(chan os.Signal, 1)
+		signal.Notify(rw.signalCh, syscall.SIGHUP)
+		go rw.goReopen()
+	}
+	ret = rw
+
+	return
+}
+
+// Открытие файла. Функция вызывается под блокировкой.
+func (rw *rotateWriter) open() (err error) {
+	const errOpen = "открытие файла %q прервано ошибкой: %s"
+	var fi os.FileInfo
+
+	if rw.file, err = os.OpenFile(rw.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, rw.opt.Perm); err != nil {
+		err = fmt.Errorf(errOpen, rw.path, err)
+		return
+	}
+	if fi, err = rw.file.Stat(); err != nil {
+		_ = rw.file.Close()
+		rw.file, err = nil, fmt.Errorf(errOpen, rw.path, err)
+		return
+	}
+	rw.size, rw.opened = fi.Size(), time.Now()
+
+	return
+}
+
+// Повторное открытие файла при получении сигнала.
+func (rw *rotateWriter) goReopen() {
+	for {
+		select {
+		case <-rw.done:
+			return
+		case <-rw.signalCh:
+			_ = rw.Reopen()
+		}
+	}
+}
+
+// Write Запись данных в файл без названия потока.
+func (rw *rotateWriter) Write(p []byte) (int, error) { return rw.plain.Write(p) }
+
+// Stream Получатель данных потока с указанным названием, записывающий данные в тот же файл.
+func (rw *rotateWriter) Stream(name string) io.Writer {
+	return &rotateStream{rw: rw, name: name, lineStart: true}
+}
+
+// Write Запись данных потока в файл.
+func (rs *rotateStream) Write(p []byte) (n int, err error) {
+	var (
+		buf []byte
+		now = time.Now()
+	)
+
+	rs.rw.sync.Lock()
+	defer rs.rw.sync.Unlock()
+	if rs.rw.closed {
+		err = os.ErrClosed
+		return
+	}
+	// Файл мог остаться закрытым после неудачного повторного открытия.
+	if rs.rw.file == nil {
+		if err = rs.rw.open(); err != nil {
+			return
+		}
+	}
+	buf = rs.format(p, now)
+	// Ошибка ротации не прерывает запись, данные записываются в текущий файл, а ошибка доступна через Error().
+	if rs.rw.rotateNeed(int64(len(buf)), now) {
+		if rs.rw.err = rs.rw.rotate(now); rs.rw.err != nil {
+			rs.rw.retry = now.Add(rotateRetry)
+		}
+	}
+	if n, err = rs.rw.file.Write(buf); err != nil {
+		return
+	}
+	rs.rw.size += int64(n)
+	n = len(p)
+
+	return
+}
+
+// Добавление времени и названия потока в начало каждой строки.
+func (rs *rotateStream) format(p []byte, now time.Time) (ret []byte) {
+	var (
+		prefix []byte
+		n      int
+	)
+
+	if !rs.rw.opt.Timestamp && (!rs.rw.opt.StreamName || rs.name == "") {
+		return p
+	}
+	if rs.rw.opt.Timestamp {
+		prefix = append(now.AppendFormat(prefix, rs.rw.opt.TimeFormat), ' ')
+	}
+	if rs.rw.opt.StreamName && rs.name != "" {
+		prefix = append(append(prefix, rs.name...), ' ')
+	}
+	ret = make([]byte, 0, len(p)+len(prefix))
+	for len(p) > 0 {
+		if rs.lineStart {
+			ret, rs.lineStart = append(ret, prefix...), false
+		}
+		if n = bytes.IndexByte(p, '\n'); n < 0 {
+			ret = append(ret, p...)
+			break
+		}
+		ret, p, rs.lineStart = append(ret, p[:n+1]...), p[n+1:], true
+	}
+
+	return
+}
+
+// Проверка необходимости ротации перед записью size байт. Функция вызывается под блокировкой.
+func (rw *rotateWriter) rotateNeed(size int64, now time.Time) bool {
+	switch {
+	case rw.size == 0 || now.Before(rw.retry):
+		return false
+	case rw.opt.MaxSize > 0 && rw.size+size > rw.opt.MaxSize:
+		return true
+	case rw.opt.Interval > 0 && now.Sub(rw.opened) >= rw.opt.Interval:
+		return true
+	default:
+		return false
+	}
+}
+
+// Rotate Принудительная ротация файла.
+func (rw *rotateWriter) Rotate() (err error) {
+	rw.sync.Lock()
+	defer rw.sync.Unlock()
+	if rw.closed {
+		return os.ErrClosed
+	}
+
+	return rw.rotate(time.Now())
+}
+
+// Ротация файла. Функция вызывается под блокировкой.
+// Открытый файл закрывается только после открытия нового файла, поэтому при ошибке ротации запись
+// продолжается в открытый файл.
+func (rw *rotateWriter) rotate(now time.Time) (err error) {
+	const errRename = "переименование файла %q прервано ошибкой: %s"
+	var (
+		name string
+		file *os.File
+		size int64
+	)
+
+	name = rw.path + "." + now.Format(rotateTimeFormat)
+	if err = os.Rename(rw.path, name); err != nil {
+		err = fmt.Errorf(errRename, rw.path, err)
+		return
+	}
+	if file, size = rw.file, rw.size; file == nil {
+		return rw.open()
+	}
+	if err = rw.open(); err != nil {
+		rw.file, rw.size = file, size
+		return
+	}
+	_ = file.Close()
+	rw.compress.Add(1)
+	go rw.goRotated(name)
+
+	return
+}
+
+// Сжатие ротированного файла и удаление устаревших ротированных файлов.
+func (rw *rotateWriter) goRotated(name string) {
+	defer rw.compress.Done()
+	if rw.opt.Compress {
+		_ = rotateCompress(name, rw.opt.Perm)
+	}
+	if rw.opt.MaxFiles > 0 {
+		rotatePrune(rw.path, rw.opt.MaxFiles)
+	}
+}
+
+// Сжатие файла gzip. Сжатый файл создаётся под временным именем, исходный файл удаляется после сжатия.
+func rotateCompress(name string, perm os.FileMode) (err error) {
+	var (
+		src, dst *os.File
+		gz       *gzip.Writer
+	)
+
+	if src, err = os.Open(name); err != nil {
+		return
+	}
+	defer func() { _ = src.Close() }()
+	if dst, err = os.OpenFile(name+rotateGzip+rotateTemp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm); err != nil {
+		return
+	}
+	gz = gzip.NewWriter(dst)
+	if _, err = io.Copy(gz, src); err == nil {
+		err = gz.Close()
+	}
+	if e := dst.Close(); err == nil {
+		err = e
+	}
+	if err == nil {
+		err = os.Rename(name+rotateGzip+rotateTemp, name+rotateGzip)
+	}
+	if err != nil {
+		_ = os.Remove(name + rotateGzip + rotateTemp)
+		return
+	}
+	err = os.Remove(name)
+
+	return
+}
+
+// Удаление самых старых ротированных файлов сверх указанного количества.
+func rotatePrune(path string, maxFiles int) {
+	var (
+		err     error
+		entries []os.DirEntry
+		prefix  string
+		names   []string
+		name    string
+	)
+
+	if entries, err = os.ReadDir(filepath.Dir(path)); err != nil {
+		return
+	}
+	prefix = filepath.Base(path) + "."
+	for _, entry := range entries {
+		if name = entry.Name(); !strings.HasPrefix(name, prefix) || strings.HasSuffix(name, rotateTemp) {
+			continue
+		}
+		// Исходный файл, сжатие которого ещё не завершено, и его сжатая копия считаются одним файлом.
+		name = strings.TrimSuffix(name, rotateGzip)
+		// Файлы, имя которых не содержит время ротации, не являются ротированными файлами.
+		if _, err = time.Parse(rotateTimeFormat, strings.TrimPrefix(name, prefix)); err != nil {
+			continue
+		}
+		if n := len(names); n > 0 && names[n-1] == name {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for len(names) > maxFiles {
+		name = filepath.Join(filepath.Dir(path), names[0])
+		_, _ = os.Remove(name), os.Remove(name+rotateGzip)
+		names = names[1:]
+	}
+}
+
+// Error Ошибка последней ротации, выполненной при записи данных, nil - если последняя ротация выполнена успешно.
+// При ошибке ротации данные продолжают записываться в текущий файл.
+func (rw *rotateWriter) Error() (err error) {
+	rw.sync.Lock()
+	err = rw.err
+	rw.sync.Unlock()
+
+	return
+}
+
+// Reopen Повторное открытие файла по тому же пути.
+func (rw *rotateWriter) Reopen() (err error) {
+	rw.sync.Lock()
+	defer rw.sync.Unlock()
+	if rw.closed {
+		return os.ErrClosed
+	}
+	if rw.file != nil {
+		_ = rw.file.Close()
+	}
+
+	return rw.open()
+}
+
+// Close Закрытие файла с ожиданием завершения сжатия ротированных файлов.
+func (rw *rotateWriter) Close() (err error) {
+	rw.sync.Lock()
+	if rw.closed {
+		rw.sync.Unlock()
+		return os.ErrClosed
+	}
+	rw.closed = true
+	if rw.signalCh != nil {
+		signal.Stop(rw.signalCh)
+	}
+	close(rw.done)
+	if rw.file != nil {
+		err = rw.file.Close()
+	}
+	rw.sync.Unlock()
+	rw.compress.Wait()
+
+	return
+}
@@ -0,0 +1,41 @@
+package run
+
+import (
+	"io"
+	"os"
+	"time"
+)
+
+// RotateOptions Настройки файла с ротацией.
+type RotateOptions struct {
+	MaxSize    int64         // Размер файла в байтах, при превышении которого выполняется ротация, 0 - без ротации по размеру.
+	Interval   time.Duration // Интервал ротации по времени, 0 - без ротации по времени.
+	MaxFiles   int           // Количество сохраняемых ротированных файлов, 0 - все файлы сохраняются.
+	Compress   bool          // Сжатие ротированных файлов gzip.
+	Timestamp  bool          // Добавление времени в начало каждой строки.
+	TimeFormat string        // Формат времени строки, по умолчанию time.RFC3339Nano.
+	StreamName bool          // Добавление названия потока в начало каждой строки.
+	Reopen     bool          // Повторное открытие файла при получении сигнала SIGHUP.
+	Perm       os.FileMode   // Права доступа создаваемых файлов, по умолчанию 0640.
+}
+
+// RotateWriter Интерфейс файла с ротацией, подключаемого к потокам процесса через StdOutWriter() и StdErrWriter().
+// Ротированные файлы получают имя исходного файла с суффиксом времени ротации, например "app.log.20240102T150405.000000000".
+type RotateWriter interface {
+	io.WriteCloser
+
+	// Stream Получатель данных потока с указанным названием, записывающий данные в тот же файл.
+	// Название потока добавляется в начало каждой строки, если установлена настройка StreamName.
+	Stream(name string) io.Writer
+
+	// Rotate Принудительная ротация файла.
+	Rotate() error
+
+	// Reopen Повторное открытие файла по тому же пути, например, после перемещения файла внешней программой.
+	Reopen() error
+
+	// Error Ошибка последней ротации, выполненной при записи данных, nil - если последняя ротация выполнена успешно.
+	// При ошибке ротации данные продолжают записываться в текущий файл, повторная ротация выполняется не ранее
+	// чем через секунду.
+	Error() error
+}
@@ -0,0 +1,202 @@
+package run
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// Ротированные файлы в порядке ротации.
+func testRotated(t *testing.T, path string) (ret []string) {
+	var err error
+
+	if ret, err = filepath.Glob(path + ".*"); err != nil {
+		t.Fatalf("ошибка поиска ротированных файлов: %v", err)
+	}
+	sort.Strings(ret)
+
+	return
+}
+
+// Содержимое файла, сжатый файл распаковывается.
+func testReadFile(t *testing.T, path string) string {
+	var (
+		err  error
+		data []byte
+		gz   *gzip.Reader
+	)
+
+	if data, err = os.ReadFile(path); err != nil {
+		t.Fatalf("ошибка чтения файла: %v", err)
+	}
+	if !strings.HasSuffix(path, rotateGzip) {
+		return string(data)
+	}
+	if gz, err = gzip.NewReader(bytes.NewReader(data)); err != nil {
+		t.Fatalf("ошибка чтения сжатого файла %q: %v", path, err)
+	}
+	if data, err = io.ReadAll(gz); err != nil {
+		t.Fatalf("ошибка распаковки файла %q: %v", path, err)
+	}
+
+	return string(data)
+}
+
+// Запись строк в файл с ротацией.
+func testRotateWrite(t *testing.T, w io.Writer, lines ...string) {
+	for _, line := range lines {
+		if n, err := w.Write([]byte(line)); err != nil || n != len(line) {
+			t.Fatalf("Write() = %d, %v, ожидается %d", n, err, len(line))
+		}
+	}
+}
+
+func TestRotateSize(t *testing.T) {
+	var (
+		path  = filepath.Join(t.TempDir(), "app.log")
+		lines = []string{"line 1\n", "line 2\n", "line 3\n", "line 4\n"}
+		all   string
+	)
+
+	rw, err := NewRotateWriter(path, &RotateOptions{MaxSize: 10, StreamName: true})
+	if err != nil {
+		t.Fatalf("ошибка создания файла: %v", err)
+	}
+	testRotateWrite(t, rw.Stream("out"), lines...)
+	if err = rw.Close(); err != nil {
+		t.Fatalf("ошибка закрытия файла: %v", err)
+	}
+	rotated := testRotated(t, path)
+	if len(rotated) != len(lines)-1 {
+		t.Errorf("ротированных файлов %d, ожидается %d", len(rotated), len(lines)-1)
+	}
+	for _, name := range append(rotated, path) {
+		all += testReadFile(t, name)
+	}
+	if expected := "out " + strings.Join(lines, "out "); all != expected {
+		t.Errorf("содержимое файлов %q, ожидается %q", all, expected)
+	}
+	if err = rw.Error(); err != nil {
+		t.Errorf("ошибка ротации: %v", err)
+	}
+}
+
+// Ротированные файлы сжимаются, сохраняется только указанное количество последних ротированных файлов.
+func TestRotateCompressPrune(t *testing.T) {
+	const maxFiles = 2
+	var path = filepath.Join(t.TempDir(), "app.log")
+
+	rw, err := NewRotateWriter(path, &RotateOptions{Compress: true, MaxFiles: maxFiles})
+	if err != nil {
+		t.Fatalf("ошибка создания файла: %v", err)
+	}
+	for n := 0; n < 5; n++ {
+		testRotateWrite(t, rw, strings.Repeat(string(rune('a'+n)), 1000)+"\n")
+		if err = rw.Rotate(); err != nil {
+			t.Fatalf("ошибка ротации: %v", err)
+		}
+	}
+	if err = rw.Close(); err != nil {
+		t.Fatalf("ошибка закрытия файла: %v", err)
+	}
+	// Сжатие и удаление ротированных файлов завершаются до возврата из Close().
+	rotated := testRotated(t, path)
+	if len(rotated) != maxFiles {
+		t.Fatalf("ротированные файлы %q, ожидается %d файла", rotated, maxFiles)
+	}
+	for n, name := range rotated {
+		if !strings.HasSuffix(name, rotateGzip) {
+			t.Errorf("ротированный файл %q не сжат", name)
+			continue
+		}
+		if data, expected := testReadFile(t, name), strings.Repeat(string(rune('d'+n)), 1000)+"\n"; data != expected {
+			t.Errorf("содержимое файла %q: %d байт, ожидается %q...", name, len(data), expected[:1])
+		}
+	}
+}
+
+// Ошибка ротации при записи не прерывает запись, данные записываются в текущий файл.
+func TestRotateError(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "app.log")
+
+	w, err := NewRotateWriter(path, &RotateOptions{MaxSize: 10})
+	if err != nil {
+		t.Fatalf("ошибка создания файла: %v", err)
+	}
+	defer func() { _ = w.Close() }()
+	rw := w.(*rotateWriter)
+	testRotateWrite(t, rw, "0123456789")
+	// Переименование удалённого файла завершается ошибкой.
+	if err = os.Remove(path); err != nil {
+		t.Fatalf("ошибка удаления файла: %v", err)
+	}
+	testRotateWrite(t, rw, "abc\n")
+	if rw.Error() == nil {
+		t.Errorf("ошибка ротации не сохранена")
+	}
+	if rw.size != 14 {
+		t.Errorf("размер текущего файла %d, ожидается 14", rw.size)
+	}
+	// Повторная ротация выполняется после открытия файла и истечения времени ожидания.
+	if err = rw.Reopen(); err != nil {
+		t.Fatalf("ошибка повторного открытия файла: %v", err)
+	}
+	testRotateWrite(t, rw, "0123456789")
+	rw.sync.Lock()
+	rw.retry = time.Time{}
+	rw.sync.Unlock()
+	testRotateWrite(t, rw, "def\n")
+	if err = rw.Error(); err != nil {
+		t.Errorf("ошибка сохранена после успешной ротации: %v", err)
+	}
+	if rotated := testRotated(t, path); len(rotated) != 1 || testReadFile(t, rotated[0]) != "0123456789" {
+		t.Errorf("ротированные файлы %q, ожидается один файл", rotated)
+	}
+	if data := testReadFile(t, path); data != "def\n" {
+		t.Errorf("содержимое файла %q, ожидается %q", data, "def\n")
+	}
+}
+
+// Файл открывается повторно по сигналу SIGHUP, например, после перемещения файла внешней программой.
+func TestRotateReopenSignal(t *testing.T) {
+	var (
+		path  = filepath.Join(t.TempDir(), "app.log")
+		moved = path + "-moved"
+	)
+
+	rw, err := NewRotateWriter(path, &RotateOptions{Reopen: true})
+	if err != nil {
+		t.Fatalf("ошибка создания файла: %v", err)
+	}
+	defer func() { _ = rw.Close() }()
+	testRotateWrite(t, rw, "before\n")
+	if err = os.Rename(path, moved); err != nil {
+		t.Fatalf("ошибка перемещения файла: %v", err)
+	}
+	if err = syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatalf("ошибка отправки сигнала: %v", err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		if _, err = os.Stat(path); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("файл не открыт повторно после сигнала SIGHUP")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	testRotateWrite(t, rw, "after\n")
+	if data := testReadFile(t, moved); data != "before\n" {
+		t.Errorf("содержимое перемещённого файла %q", data)
+	}
+	if data := testReadFile(t, path); data != "after\n" {
+		t.Errorf("содержимое нового файла %q", data)
+	}
+}
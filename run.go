@@ -6,7 +6,6 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"strings"
 	"sync"
 	"syscall"
@@ -16,49 +15,65 @@ import (
 // New Конструктор объекта сущности пакета.
 func New() Interface {
 	var run = &impl{
-		bufLen:  bufLength,
-		chanLen: chanLength,
-		bufInp:  &bytes.Buffer{},
-		bufOut:  &bytes.Buffer{},
-		bufErr:  &bytes.Buffer{},
+		bufInp: &bytes.Buffer{},
+		// Подписчики потоков сохраняются до завершения процесса или сброса.
+		streamOut: newStream(),
+		streamErr: newStream(),
+		// Объекты синхронизации создаются один раз и сохраняются при повторном использовании.
+		fieldSync:   new(sync.RWMutex),
+		processSync: new(sync.Mutex),
+		logSync:     new(sync.RWMutex),
+		eventSync:   new(sync.Mutex),
+		stateSync:   new(sync.Mutex),
+		stateCh:     make(chan struct{}),
 	}
 	run.err = run.init()
 	return run
 }
 
-func (run *impl) debug(format string, args ...any) {
-	if !run.debugMode {
-		return
-	}
-	println(fmt.Sprintf(format, args...))
+// Объект процесса, который не удалось запустить до создания процесса, например из-за ошибки подстановки данных.
+// Объект не владеет файловыми дескрипторами: трубы закрываются сразу, а состояние StateFailed исключает запуск
+// до вызова функции Reset().
+func newFailed(op string, err error) (ret *impl) {
+	ret = New().(*impl)
+	ret.pipesClose()
+	_ = ret.stateSet(op, StateFailed)
+	ret.errSet(err)
+
+	return
 }
 
 // Инициализатор объекта пакета.
 // Функция вызывается так же при сбросе данных пакета, для переиспользования.
 func (run *impl) init() (err error) {
 	const (
-		msgInitBeg = "инициализация пакета, начато"
-		msgInitEnd = "инициализация пакета, завершено"
+		opReset    = "Reset"
+		msgInitBeg = "init.begin"
+		msgInitEnd = "init.end"
 		errPipeInp = "создание трубы для STDIN прервано ошибкой: %s"
 		errPipeOut = "создание трубы для STDOUT прервано ошибкой: %s"
 		errPipeErr = "создание трубы для STDERR прервано ошибкой: %s"
 	)
 
-	run.debug(msgInitBeg)
+	run.log(LevelDebug, msgInitBeg)
 	run.err = nil
 	run.cmd = run.cmd[:0]
 	run.context = nil
-	run.processSync = new(sync.Mutex)
 	run.process = nil
+	_ = run.stateSet(opReset, StateConfigured)
 	run.processStatus = nil
+	run.result = nil
+	run.redirects = [3]*redirect{}
+	run.extraFiles, run.sockets = nil, nil
+	run.envFiles, run.envChanges, run.envClean = nil, nil, false
+	run.shell, run.shellStrict = shellDefault, false
+	run.scriptMode, run.scriptFile = ScriptTempFile, nil
+	run.tempDir = nil
 	run.processWait = new(sync.WaitGroup)
-	// Каналы взаимодействия с потоками.
+	run.bufLen, run.chanLen = bufLength, chanLength
+	// Канал STDIN создаётся при запуске процесса с учётом установленного размера буфера канала.
 	chanClose(run.stdinpCh)
-	run.stdinpCh = make(chan []byte, run.chanLen)
-	chanClose(run.stdOutCh)
-	run.stdOutCh = make(chan []byte, run.chanLen)
-	chanClose(run.stdErrCh)
-	run.stdErrCh = make(chan []byte, run.chanLen)
+	run.stdinpCh = nil
 	// Канал передачи сигнала о завершении вспомогательной горутины обработки данных.
 	chanClose(run.doneData)
 	run.doneData = make(chan struct{})
@@ -69,18 +84,17 @@ func (run *impl) init() (err error) {
 	run.doneOut = make(chan struct{})
 	chanClose(run.doneErr)
 	run.doneErr = make(chan struct{})
-	// Буферизированный канал для обработки событие поступления новых данных в STDIN.
-	chanClose(run.onNewData)
-	run.onNewData = make(chan struct{}, run.chanLen)
+	// Канал передачи сигнала о поступлении новых данных в STDIN, повторные сигналы объединяются.
+	// Канал не закрывается, так как функция StdIn() может отправлять в него сигнал одновременно со сбросом.
+	run.onNewData = make(chan struct{}, 1)
 	run.bufInp.Reset()
-	run.bufOut.Reset()
-	run.bufErr.Reset()
+	// Трубы предыдущего запуска закрыты после завершения процесса, а трубы не запускавшегося процесса
+	// закрываются здесь.
+	run.pipesClose()
 	// Каналы обмена данными потоков с внешними источниками и получателями.
 	run.externalInpCh = nil
-	chanClose(run.externalOutCh)
-	run.externalOutCh = nil
-	chanClose(run.externalErrCh)
-	run.externalErrCh = nil
+	run.streamOut.reset()
+	run.streamErr.reset()
 	// Потоки взаимодействия с запускаемым приложением.
 	if run.pipeInpReader, run.pipeInpWriter, err = os.Pipe(); err != nil {
 		err = fmt.Errorf(errPipeInp, err)
@@ -94,123 +108,359 @@ func (run *impl) init() (err error) {
 		err = fmt.Errorf(errPipeErr, err)
 		return
 	}
+	run.cgroupPath = ""
+	run.schedule = nil
 	run.attributes = &os.ProcAttr{}
 	run.attributes.Files = make([]*os.File, 0, 3)
 	run.attributes.Files = append(run.attributes.Files, run.pipeInpReader) // STDIN
 	run.attributes.Files = append(run.attributes.Files, run.pipeOutWriter) // STDOUT
 	run.attributes.Files = append(run.attributes.Files, run.pipeErrWriter) // STDERR
-	run.debug(msgInitEnd)
+	run.log(LevelDebug, msgInitEnd)
 
 	return
 }
 
-// Debug Установка режима отладки.
-func (run *impl) Debug(isDebug bool) Interface { run.debugMode = isDebug; return run }
-
 // Error Ошибка, возникшая в функции не возвращающей ошибки.
-func (run *impl) Error() error { return run.err }
+func (run *impl) Error() (err error) {
+	run.fieldSync.RLock()
+	err = run.err
+	run.fieldSync.RUnlock()
+
+	return
+}
+
+// Установка ошибки, возвращаемой функцией Error().
+func (run *impl) errSet(err error) {
+	run.fieldSync.Lock()
+	run.err = err
+	run.fieldSync.Unlock()
+}
+
+// Удаление описания завершившегося процесса.
+func (run *impl) processClear() {
+	run.fieldSync.Lock()
+	run.process = nil
+	run.fieldSync.Unlock()
+}
+
+// Описание запущенного процесса, или nil, если процесс не запущен.
+func (run *impl) processGet() (ret *os.Process) {
+	run.fieldSync.RLock()
+	ret = run.process
+	run.fieldSync.RUnlock()
+
+	return
+}
 
 // Run Запуск приложения и возвращение из функции без ожидания завершения приложения.
 // Если передан контекст не равный nil, тогда прерывание через контекст завершает работу приложения аналогично
 // вызову функции Kill().
 func (run *impl) Run(ctx context.Context, args ...string) Interface {
+	run.runStart(ctx, args...)
+	return run
+}
+
+// Запуск приложения, возвращается истина, если процесс был запущен, в том числе если после запуска он был
+// завершён из-за ошибки, и его завершения следует ожидать.
+func (run *impl) runStart(ctx context.Context, args ...string) (started bool) {
 	const (
-		errRunAlready = "процесс уже запущен, " +
-			"либо пакет используется, выполните функцию Reset(), перед повторным использованием"
+		opRun       = "Run"
 		errWorkdir  = "указана не доступная рабочая директория %q, ошибка: %s"
 		errProg     = "не указана программа для запуска"
-		errProgPath = "поиск программы %q прерван ошибкой: %s"
+		errVeto     = "запуск процесса отменён: %s"
+		errTempDir  = "создание временной рабочей директории прервано ошибкой: %s"
+		errProgPath = "поиск программы %q прерван ошибкой: %w"
 		errProc     = "выполнение процесса %q прервано ошибкой: %s"
-		msgGoBeg    = "запуск вспомогательных горутин, начат"
-		msgGoEnd    = "запуск вспомогательных горутин, окончен"
-		msgProc     = "запуск процесса: %s"
+		errFiles    = "передача файлов процессу %q прервана ошибкой: %s"
+		errCgroup   = "процесс %d завершён, так как не был помещён в группу cgroup: %s"
+		errCgroupFd = "открытие группы cgroup %q прервано ошибкой: %s"
+		errSchedule = "недопустимые настройки планирования: %s"
+		errOOMScore = "process.oom_score_adj.failed"
+		msgGoBeg    = "helpers.start.begin"
+		msgGoEnd    = "helpers.start.end"
+		msgProc     = "process.start"
 	)
 	var (
+		err            error
 		proc           string
+		chroot         string
+		cmd            []string
+		process        *os.Process
+		cgroupPath     string
+		sched          *schedule
+		req            *StartRequest
+		bufLen         int
+		chanLen        int
+		start          string
+		tempDir        string
+		argv           []string
+		opened         []*os.File
+		redirected     [3]bool
+		helpers        bool
+		cgroupFh       *os.File
+		cgroupLate     bool
+		procAttr       os.ProcAttr
 		doneBeg        chan struct{}
+		startEnd       chan struct{}
 		processContext context.Context    // Контекст завершения вспомогательной горутины обработки данных.
 		processCancel  context.CancelFunc // Функция завершения вспомогательной горутины обработки данных.
 	)
 
+	// После запуска процесса блокировка передаётся горутине ожидания завершения процесса и снимается ею после
+	// завершения процесса, поэтому сброс не может быть выполнен между запуском процесса и началом его ожидания.
 	run.processSync.Lock()
-	defer run.processSync.Unlock()
-	if run.process != nil {
-		run.err = fmt.Errorf(errRunAlready)
-		return run
+	startEnd = make(chan struct{})
+	defer func() {
+		if started {
+			close(startEnd)
+			return
+		}
+		run.processSync.Unlock()
+	}()
+	// Повторный запуск возможен только после вызова функции Reset().
+	if err = run.stateSet(opRun, StateStarting); err != nil {
+		run.errSet(err)
+		return
 	}
-	run.processStatus = nil
+	// Любой выход из функции до запуска процесса означает ошибку запуска.
+	defer func() {
+		if run.State() == StateStarting {
+			run.pipesRelease(helpers)
+			_ = run.stateSet(opRun, StateFailed)
+		}
+	}()
+	run.fieldSync.Lock()
+	run.processStatus, run.result, run.context = nil, nil, ctx
+	err, bufLen, chanLen = run.err, run.bufLen, run.chanLen
+	run.stdinpCh = make(chan []byte, chanLen)
+	req = &StartRequest{Args: append([]string{}, args...), Env: run.envStart(), Dir: run.attributes.Dir}
+	run.fieldSync.Unlock()
 	// Если была ошибка в процессе инициализации, возвращаем её сейчас.
-	if run.err != nil {
-		return run
+	if err != nil {
+		return
 	}
-	run.context = ctx
-	if run.context != nil {
-		processContext, processCancel = context.WithCancel(run.context)
+	if ctx != nil {
+		processContext, processCancel = context.WithCancel(ctx)
 	} else {
 		processContext, processCancel = context.WithCancel(context.Background())
 	}
-	// Рабочая директория.
-	if run.attributes.Dir != "" {
-		if _, run.err = os.Stat(run.attributes.Dir); run.err != nil {
-			run.err = fmt.Errorf(errWorkdir, run.attributes.Dir, run.err)
+	// Временная рабочая директория создаётся для каждого запуска и удаляется, если процесс не был запущен.
+	if tempDir, err = run.tempDirCreate(); err != nil {
+		run.errSet(fmt.Errorf(errTempDir, err))
+		processCancel()
+		return
+	}
+	if tempDir != "" {
+		req.Dir = tempDir
+		defer func() {
+			if run.State() == StateStarting {
+				run.tempDirFinish(nil)
+			}
+		}()
+	}
+	// Функции подготовки к запуску могут изменить параметры запуска, или отменить запуск.
+	if err = run.fireBeforeStart(req); err != nil {
+		run.errSet(fmt.Errorf(errVeto, err))
+		processCancel()
+		return
+	}
+	// Рабочая директория запуска не сохраняется в настройках объекта, так как временная рабочая директория
+	// удаляется после завершения процесса.
+	args = req.Args
+	run.fieldSync.Lock()
+	run.attributes.Env = req.Env
+	if run.attributes.Sys != nil {
+		chroot = run.attributes.Sys.Chroot
+	}
+	cgroupPath, sched = run.cgroupPath, run.schedule
+	run.fieldSync.Unlock()
+	// Рабочая директория, в chroot путь указывается относительно директории chroot.
+	if req.Dir != "" {
+		if err = workdirCheck(req.Dir, chroot); err != nil {
+			run.errSet(fmt.Errorf(errWorkdir, req.Dir, err))
 			processCancel()
-			return run
+			return
 		}
 	}
 	// Проверка запускаемой программы.
 	if len(args) == 0 {
-		run.err = errors.New(errProg)
+		run.errSet(errors.New(errProg))
+		processCancel()
+		return
+	}
+	// Программа ищется так, как её найдёт процесс: по PATH окружения процесса, в рабочей директории и chroot.
+	// Настройки планирования проверяются до запуска процесса.
+	if err = sched.check(); err != nil {
+		run.errSet(fmt.Errorf(errSchedule, err))
 		processCancel()
-		return run
+		return
 	}
-	if proc = run.LookPath(args[0]); run.err != nil {
+	if proc, err = lookPath(args[0], req.Env, req.Dir, chroot); err != nil {
+		run.errSet(fmt.Errorf(errProgPath, args[0], err))
 		processCancel()
-		run.err = fmt.Errorf(errProgPath, args[0], run.err)
-		return run
+		return
 	}
+	// Перенаправление потоков в файлы, процесс получает копии файловых дескрипторов, поэтому файлы пакета
+	// закрываются после запуска процесса.
+	if opened, redirected, err = run.redirectApply(); err != nil {
+		run.errSet(err)
+		processCancel()
+		return
+	}
+	defer func() {
+		for _, fh := range opened {
+			_ = fh.Close()
+		}
+	}()
 	// Запуск вспомогательных горутин с контролем того что они уже запустились и работаю.
+	// Для перенаправленных потоков горутины не запускаются, а каналы сигнала завершения закрываются сразу.
 	doneBeg = make(chan struct{})
-	run.debug(msgGoBeg)
+	run.log(LevelDebug, msgGoBeg)
 	// STDIN
-	go run.goWriter(doneBeg, run.doneInp, run.pipeInpWriter, run.stdinpCh)
-	<-doneBeg // Ожидание гарантированного старта горутины.
-	// STDOUT
-	go run.goReader(doneBeg, run.doneOut, run.stdOutCh, run.pipeOutReader)
-	<-doneBeg // Ожидание гарантированного старта горутины.
-	// STDERR
-	go run.goReader(doneBeg, run.doneErr, run.stdErrCh, run.pipeErrReader)
-	<-doneBeg // Ожидание гарантированного старта горутины.
-	run.debug(msgGoEnd)
+	if redirected[redirectInp] {
+		chanClose(run.doneInp)
+	} else {
+		go run.goWriter(doneBeg, run.doneInp, "stdin", run.pipeInpWriter, run.stdinpCh)
+		<-doneBeg // Ожидание гарантированного старта горутины.
+	}
+	// STDOUT и STDERR обрабатываются независимо, медленный подписчик одного потока не задерживает другой поток.
+	if redirected[redirectOut] {
+		chanClose(run.doneOut)
+	} else {
+		go run.goReader(doneBeg, run.doneOut, "stdout", run.streamOut, run.pipeOutReader, bufLen)
+		<-doneBeg // Ожидание гарантированного старта горутины.
+	}
+	if redirected[redirectErr] {
+		chanClose(run.doneErr)
+	} else {
+		go run.goReader(doneBeg, run.doneErr, "stderr", run.streamErr, run.pipeErrReader, bufLen)
+		<-doneBeg // Ожидание гарантированного старта горутины.
+	}
+	run.log(LevelDebug, msgGoEnd)
+	helpers = true
+	// Прерывание через контекст отключает подписчиков, ожидающих приёма данных, до закрытия потоков.
+	run.streamOut.cancelOn(ctx)
+	run.streamErr.cancelOn(ctx)
 	// Запуск процесса.
-	run.cmd = make([]string, 0, len(args))
-	run.cmd = append([]string{proc}, args[1:]...)
-	run.debug(msgProc, strings.Join(run.cmd, " "))
-	if run.process, run.err = os.StartProcess(proc, run.cmd, run.attributes); run.err != nil {
-		run.err = fmt.Errorf(errProc, proc, run.err)
+	cmd = append([]string{proc}, args[1:]...)
+	if start, argv, err = run.filesApply(proc, cmd); err != nil {
+		run.errSet(fmt.Errorf(errFiles, proc, err))
+		processCancel()
+		return
+	}
+	// Процесс помещается в группу cgroup при создании, поэтому потомки, созданные им сразу после запуска, не
+	// оказываются вне группы. Если ядро не поддерживает запуск в группе, процесс помещается в группу после запуска.
+	if cgroupPath != "" {
+		if cgroupFh, err = os.Open(cgroupPath); err != nil {
+			run.errSet(fmt.Errorf(errCgroupFd, cgroupPath, err))
+			processCancel()
+			return
+		}
+		defer func() { _ = cgroupFh.Close() }()
+	}
+	run.log(LevelInfo, msgProc, attr("argv", cmd), attr("dir", req.Dir))
+	run.fieldSync.Lock()
+	run.cmd = cmd
+	procAttr = *run.attributes
+	procAttr.Dir = req.Dir
+	cgroupLate = cgroupPath != "" && !cgroupClone(&procAttr, cgroupFh)
+	// Настройки планирования процесс наследует при запуске, ошибка их применения отменяет запуск.
+	process, err = scheduleStart(start, argv, &procAttr, sched)
+	if cgroupFh != nil && !cgroupLate && cgroupCloneUnsupported(err) {
+		cgroupLate = cgroupClone(&procAttr, nil)
+		process, err = scheduleStart(start, argv, &procAttr, sched)
+	}
+	if cgroupFh != nil {
+		_ = cgroupClone(&procAttr, nil)
+	}
+	if err == nil {
+		run.process = process
+		run.processWait.Add(1)
+	}
+	run.fieldSync.Unlock()
+	if err != nil {
+		run.errSet(fmt.Errorf(errProc, proc, err))
 		processCancel()
-		return run
+		return
 	}
+	started = true
+	_ = run.stateSet(opRun, StateRunning)
 	// Запуск вспомогательной горутины обработки данных.
-	go run.goProcessData(doneBeg, run.doneData, processContext)
+	go run.goProcessData(doneBeg, run.doneData, processContext, bufLen, !redirected[redirectInp])
 	<-doneBeg
 	// Запуск вспомогательной горутины ожидания завершения процесса.
-	run.processWait.Add(1)
-	go run.goProcessWait(doneBeg, processCancel)
+	go run.goProcessWait(doneBeg, startEnd, process, processCancel)
 	<-doneBeg
 	chanClose(doneBeg)
+	// Помещение процесса в группу cgroup после запуска, если ядро не поддерживает запуск в группе,
+	// при ошибке процесс завершается.
+	if cgroupLate {
+		if err = cgroupAttach(cgroupPath, process.Pid); err != nil {
+			run.errSet(fmt.Errorf(errCgroup, process.Pid, err))
+			_ = process.Kill()
+		}
+	}
+	// Значение oom_score_adj устанавливается после запуска, ошибка не прерывает выполнение процесса и не является
+	// ошибкой запуска, она передаётся в журнал и в событие EventStarted.
+	if err == nil {
+		if err = scheduleOOM(process.Pid, sched); err != nil {
+			run.log(LevelWarn, errOOMScore, attr("pid", process.Pid), attr("error", err))
+		}
+	}
+	run.fire(Event{Type: EventStarted, Pid: process.Pid, Args: cmd, Err: err})
 
-	return run
+	return
+}
+
+// Проверка рабочей директории процесса. В chroot путь проверяется относительно директории chroot.
+func workdirCheck(dir string, chroot string) (err error) {
+	if chroot != "" {
+		if dir, err = chrootResolve(chroot, dir); err != nil {
+			return
+		}
+	}
+	_, err = os.Stat(dir)
+
+	return
+}
+
+// Освобождение труб взаимодействия с процессом, если процесс не был запущен. Если вспомогательные горутины
+// потоков запущены, они завершаются после закрытия канала STDIN и труб записи, а затем закрываются все трубы.
+func (run *impl) pipesRelease(helpers bool) {
+	if helpers {
+		chanClose(run.stdinpCh)
+		_ = run.pipeOutWriter.Close()
+		_ = run.pipeErrWriter.Close()
+		<-run.doneInp
+		<-run.doneOut
+		<-run.doneErr
+	}
+	run.pipesClose()
+}
+
+// Закрытие всех труб взаимодействия с процессом, ошибки закрытия уже закрытых труб не учитываются.
+func (run *impl) pipesClose() {
+	for _, fh := range []*os.File{
+		run.pipeInpReader, run.pipeInpWriter,
+		run.pipeOutReader, run.pipeOutWriter,
+		run.pipeErrReader, run.pipeErrWriter,
+	} {
+		if fh != nil {
+			_ = fh.Close()
+		}
+	}
 }
 
 // RunWait Запуск приложения и ожидание завершения приложения.
 // Если передан контекст не равный nil, тогда прерывание через контекст завершает работу приложения аналогично
 // вызову функции Kill().
+// После завершения приложения использование ресурсов доступно через функции Usage() и Result().
 func (run *impl) RunWait(ctx context.Context, args ...string) (ret *os.ProcessState, err error) {
 	const errAlreadyFinished = "already finished"
 
-	if err = run.
-		Run(ctx, args...).
-		Error(); err != nil {
+	// Запущенный процесс ожидается всегда, даже если после запуска возникла ошибка.
+	if !run.runStart(ctx, args...) {
+		err = run.Error()
 		return
 	}
 	if ret, err = run.Wait(); err != nil {
@@ -224,70 +474,125 @@ func (run *impl) RunWait(ctx context.Context, args ...string) (ret *os.ProcessSt
 }
 
 // Wait Ожидание завершения ранее запущенного приложения.
+// После завершения приложения использование ресурсов доступно через функции Usage() и Result().
 func (run *impl) Wait() (ret *os.ProcessState, err error) {
-	const errRun = "процесс не запущен"
-	if run.process == nil {
-		err = fmt.Errorf(errRun)
+	const opWait = "Wait"
+	var wg *sync.WaitGroup
+
+	// Состояние и ожидаемый процесс читаются вместе, так как сброс изменяет их под блокировкой fieldSync.
+	run.fieldSync.RLock()
+	wg, err = run.processWait, run.stateIs(opWait, StateRunning, StatePaused, StateStopping, StateExited)
+	run.fieldSync.RUnlock()
+	if err != nil {
 		return
 	}
-	run.processWait.Wait()
-	if run.processStatus != nil {
-		ret, err = run.processStatus, run.err
-		return
+	wg.Wait()
+	// Если во время ожидания был выполнен сброс, состояние ожидаемого процесса уже удалено.
+	run.fieldSync.RLock()
+	if ret, err = run.processStatus, run.err; run.processWait != wg {
+		ret, err = nil, &StateError{Op: opWait, State: StateConfigured}
 	}
+	run.fieldSync.RUnlock()
 
 	return
 }
 
-// LookPath Выполнение одноимённой утилиты exec.LookPath(), чтобы тыла под рукой,
-// Ошибку выполнения функции можно получить через Error().
-func (run *impl) LookPath(proc string) (ret string) { ret, run.err = exec.LookPath(proc); return }
-
 // Pid Возвращает PID процесса. Если процесс не был запущен, возвращается -1.
 func (run *impl) Pid() int {
-	if run.process == nil {
+	var process = run.processGet()
+
+	if process == nil {
 		return -1
 	}
-	return run.process.Pid
+	return process.Pid
 }
 
 // Signal Отправка сигнала ранее запущенному приложению.
-func (run *impl) Signal(sig os.Signal) error {
-	const errRun = "процесс не запущен"
-	if run.process == nil {
+// Перед отправкой сигнала завершения приостановленному приложению его выполнение возобновляется, иначе
+// сигнал не будет обработан до вызова Resume().
+func (run *impl) Signal(sig os.Signal) (err error) {
+	const (
+		opSignal  = "Signal"
+		errRun    = "процесс не запущен"
+		errResume = "process.resume.failed"
+	)
+	var process *os.Process
+
+	if err = run.stateIs(opSignal, StateRunning, StatePaused, StateStopping); err != nil {
+		return
+	}
+	// Ошибка возобновления не прерывает отправку сигнала: процесс мог быть возобновлён или завершён одновременно.
+	if signalTerminates(sig) && run.State() == StatePaused {
+		if e := run.Resume(); e != nil {
+			run.log(LevelWarn, errResume, attr("pid", run.Pid()), attr("error", e))
+		}
+	}
+	if process = run.processGet(); process == nil {
 		return errors.New(errRun)
 	}
-	return run.process.Signal(sig)
+	if err = process.Signal(sig); err == nil {
+		run.fire(Event{Type: EventSignalSent, Pid: process.Pid, Args: run.Command(), Signal: sig})
+	}
+
+	return
 }
 
 // Kill Завершение ранее запущенного приложения.
-func (run *impl) Kill() error {
-	const errRun = "процесс не запущен"
-	if run.process == nil {
+func (run *impl) Kill() (err error) {
+	const (
+		opKill = "Kill"
+		errRun = "процесс не запущен"
+	)
+	var process *os.Process
+
+	if err = run.stateIs(opKill, StateRunning, StatePaused, StateStopping); err != nil {
+		return
+	}
+	if process = run.processGet(); process == nil {
 		return errors.New(errRun)
 	}
-	return run.process.Kill()
+	if err = process.Kill(); err == nil {
+		if run.State() != StateStopping {
+			_ = run.stateSet(opKill, StateStopping)
+		}
+		run.fire(Event{Type: EventSignalSent, Pid: process.Pid, Args: run.Command(), Signal: os.Kill})
+	}
+
+	return
 }
 
 // Release Освобождение всех ресурсов запущенного приложения.
 // Release необходимо выполнять только в случае если Wait() не работает.
-func (run *impl) Release() error {
-	const errRun = "процесс не запущен"
-	if run.process == nil {
+func (run *impl) Release() (err error) {
+	const (
+		opRelease = "Release"
+		errRun    = "процесс не запущен"
+	)
+	var process *os.Process
+
+	if err = run.stateIs(opRelease, StateRunning, StatePaused, StateStopping); err != nil {
+		return
+	}
+	if process = run.processGet(); process == nil {
 		return errors.New(errRun)
 	}
-	return run.process.Release()
+	if err = process.Release(); err == nil {
+		run.fire(Event{Type: EventReleased, Pid: process.Pid, Args: run.Command()})
+	}
+
+	return
 }
 
 // Reset Завершение приложения, если оно было запущено, сброс всех настроек и подготовка пакета для
-// повторного использования.
+// повторного использования. Установленный журнал сохраняется.
 func (run *impl) Reset() Interface {
 	const (
 		tryCount   = 4
-		msgSigTerm = "передача процессу %d сигнала SIGTERM (#16)"
-		msgSigKill = "передача процессу %d сигнала SIGKILL (#9)"
-		msgRelease = "освобождение процесса %d"
-		errRelease = "освобождение процесса %d прервано ошибкой: %s"
+		msgSignal  = "process.signal"
+		msgRelease = "process.release"
+		errRelease = "process.release.failed"
+		errResume  = "process.resume.failed"
+		opReset    = "Reset"
 	)
 	var (
 		err  error
@@ -296,37 +601,52 @@ func (run *impl) Reset() Interface {
 		pid  int
 	)
 
-	if run.process != nil {
-		pid = run.process.Pid
+	// Приостановленный процесс не обработает сигнал SIGTERM, поэтому его выполнение возобновляется.
+	if run.State() == StatePaused {
+		if err = run.Resume(); err != nil {
+			run.log(LevelWarn, errResume, attr("pid", run.Pid()), attr("error", err))
+		}
+	}
+	if state := run.State(); state == StateRunning || state == StatePaused {
+		_ = run.stateSet(opReset, StateStopping)
+	}
+	if pid = run.Pid(); pid > 0 {
 		if proc, err = os.FindProcess(pid); err == nil {
-			run.debug(msgSigTerm, pid)
+			run.log(LevelInfo, msgSignal, attr("pid", pid), attr("signal", syscall.SIGTERM))
 			for try = 0; try < tryCount && err == nil; try++ {
-				err = proc.Signal(syscall.SIGTERM)
+				if err = proc.Signal(syscall.SIGTERM); err == nil {
+					run.fire(Event{Type: EventSignalSent, Pid: pid, Args: run.Command(), Signal: syscall.SIGTERM})
+				}
 				<-time.After(time.Second)
 			}
 		}
 	}
-	if run.process != nil {
-		pid = run.process.Pid
+	if pid = run.Pid(); pid > 0 {
 		if proc, err = os.FindProcess(pid); err == nil {
-			run.debug(msgSigKill, pid)
+			run.log(LevelInfo, msgSignal, attr("pid", pid), attr("signal", syscall.SIGKILL))
 			for try = 0; try < tryCount/2 && err == nil; try++ {
-				err = proc.Kill()
+				if err = proc.Kill(); err == nil {
+					run.fire(Event{Type: EventSignalSent, Pid: pid, Args: run.Command(), Signal: os.Kill})
+				}
 				<-time.After(time.Second)
 			}
 		}
 	}
-	if run.process != nil {
-		pid = run.process.Pid
-		run.debug(msgRelease, pid)
+	if pid = run.Pid(); pid > 0 {
+		run.log(LevelDebug, msgRelease, attr("pid", pid))
 		if err = run.Release(); err != nil {
-			run.debug(errRelease, pid, err)
+			run.log(LevelWarn, errRelease, attr("pid", pid), attr("error", err))
 		}
 	}
+	// Ожидание завершения вспомогательных горутин и сброс пакета. Блокировка процесса удерживается на время
+	// сброса, чтобы исключить одновременный запуск процесса из другой горутины.
 	run.processSync.Lock()
-	run.processSync.Unlock()
-	run.debugMode = false
+	run.fieldSync.Lock()
 	run.err = run.init()
+	err = run.err
+	run.fieldSync.Unlock()
+	run.processSync.Unlock()
+	run.fire(Event{Type: EventReset, Pid: -1, Err: err})
 
 	return run
 }
@@ -2,10 +2,14 @@ package run
 
 import (
 	"context"
+	"io"
 	"os"
+	"time"
 )
 
 // Interface Интерфейс пакета.
+// Все функции интерфейса безопасны для одновременного вызова из разных горутин, в том числе Signal(), Kill(),
+// Pause() и Reset() во время выполнения Wait() или RunWait(). Запуск процесса и сброс выполняются монопольно.
 type Interface interface {
 	// Входящие и исходящие данные (STDIN, STDOUT, STDERR).
 	// Функции с каналами и со срезом байт могут быть использованы одновременно, учитывая особенность того что функции
@@ -14,33 +18,141 @@ type Interface interface {
 
 	// StdInCh Канал с данными для потока STDIN. Канал должен быть закрыт там же где открывался.
 	// Функция читает канал и передаёт процессу данные, до тех пор пока канал открыт и процесс запущен.
+	// Срезы передаются процессу без копирования и не должны изменяться после отправки в канал.
 	StdInCh(ch <-chan []byte) Interface
 
 	// StdIn Данные, отправляемые процессу в поток STDIN после запуска процесса.
 	StdIn(buf []byte) Interface
 
 	// StdOutCh Канал с данными полученными из процесса через поток STDOUT. Канал будет закрыт после завершения
-	// процесса. Канал не создаётся, если функция не вызывалась.
+	// процесса. Каждый вызов функции создаёт нового подписчика, получающего все новые данные потока.
 	StdOutCh() (ret <-chan []byte)
 
+	// StdOutSubscribe Подписка на данные потока STDOUT. Подписка возможна как до, так и после запуска процесса.
+	// Подписчик может получить данные, полученные до подписки, и имеет собственный буфер канала и политику
+	// поведения при переполнении буфера. Если настройки не указаны, подписчик получает только новые данные,
+	// а передача данных ожидает освобождения буфера канала подписчика. Ожидание приостанавливает только чтение
+	// потока STDOUT, после заполнения трубы процесс блокируется при записи в STDOUT.
+	StdOutSubscribe(opt *SubscribeOptions) Subscription
+
+	// StdOutWriter Получатель данных потока STDOUT. Установка получателя отключает сохранение данных потока,
+	// данные передаются получателю и подписчикам. Если получателем является файл, а у потока нет подписчиков,
+	// данные передаются в файл системным вызовом splice, минуя память процесса. Значение nil удаляет получателя.
+	StdOutWriter(w io.Writer) Interface
+
 	// StdOut Данные, полученные от процесса через поток STDOUT.
 	StdOut() (ret []byte)
 
 	// StdErrCh Канал с данными полученными из процесса через поток STDERR. Канал будет закрыт после завершения
-	// процесса. Канал не создаётся, если функция не вызывалась.
+	// процесса. Каждый вызов функции создаёт нового подписчика, получающего все новые данные потока.
 	StdErrCh() (ret <-chan []byte)
 
+	// StdErrSubscribe Подписка на данные потока STDERR. Подписка возможна как до, так и после запуска процесса.
+	// Подписчик может получить данные, полученные до подписки, и имеет собственный буфер канала и политику
+	// поведения при переполнении буфера. Если настройки не указаны, подписчик получает только новые данные,
+	// а передача данных ожидает освобождения буфера канала подписчика. Ожидание приостанавливает только чтение
+	// потока STDERR, после заполнения трубы процесс блокируется при записи в STDERR.
+	StdErrSubscribe(opt *SubscribeOptions) Subscription
+
+	// StdErrWriter Получатель данных потока STDERR. Установка получателя отключает сохранение данных потока,
+	// данные передаются получателю и подписчикам. Если получателем является файл, а у потока нет подписчиков,
+	// данные передаются в файл системным вызовом splice, минуя память процесса. Значение nil удаляет получателя.
+	StdErrWriter(w io.Writer) Interface
+
 	// StdErr Данные, полученные от процесса через поток STDERR.
 	StdErr() (ret []byte)
 
+	// Перенаправление потоков. Процесс получает файловый дескриптор напрямую, без трубы и горутин пакета,
+	// функции сохранения данных, каналов и подписки для перенаправленных потоков не работают.
+
+	// StdInFile Процесс получает данные потока STDIN из указанного файла напрямую, без трубы и горутин пакета.
+	// Данные, переданные через StdIn() и StdInCh(), процессу не передаются.
+	StdInFile(path string) Interface
+
+	// StdOutFile Процесс записывает данные потока STDOUT в указанный файл напрямую, без трубы и горутин пакета.
+	// Если флаги не указаны, файл открывается с флагами os.O_WRONLY|os.O_CREATE|os.O_TRUNC.
+	// Данные потока не сохраняются и не передаются подписчикам и получателю StdOutWriter().
+	StdOutFile(path string, flags int, perm os.FileMode) Interface
+
+	// StdOutNull Данные потока STDOUT процесса отбрасываются.
+	StdOutNull() Interface
+
+	// StdErrFile Процесс записывает данные потока STDERR в указанный файл напрямую, без трубы и горутин пакета.
+	// Если флаги не указаны, файл открывается с флагами os.O_WRONLY|os.O_CREATE|os.O_TRUNC.
+	// Данные потока не сохраняются и не передаются подписчикам и получателю StdErrWriter().
+	StdErrFile(path string, flags int, perm os.FileMode) Interface
+
+	// StdErrNull Данные потока STDERR процесса отбрасываются.
+	StdErrNull() Interface
+
+	// StdErrToStdOut Поток STDERR процесса перенаправляется в поток STDOUT, аналогично "2>&1".
+	// Данные обоих потоков поступают в поток STDOUT, в том числе в файл, указанный через StdOutFile().
+	StdErrToStdOut() Interface
+
+	// ExtraFiles Дополнительные файлы, передаваемые процессу как файловые дескрипторы, начиная с 3.
+	// Если указаны сокеты активации, дополнительные файлы передаются после них. Значение nil означает, что
+	// соответствующий файловый дескриптор в процессе закрыт. Файлы не закрываются пакетом.
+	ExtraFiles(files ...*os.File) Interface
+
+	// SocketActivation Передача процессу сокетов по протоколу активации сокетов systemd: сокеты передаются как
+	// файловые дескрипторы, начиная с 3, а процессу устанавливаются переменные окружения LISTEN_FDS,
+	// LISTEN_FDNAMES и LISTEN_PID. Для установки LISTEN_PID процесс запускается через /bin/sh, который должен
+	// быть доступен, в том числе в chroot. Файлы не закрываются пакетом.
+	SocketActivation(sockets ...ActivationSocket) Interface
+
 	// Настройки запуска приложения.
 
 	// WorkingDirectory Назначение директории выполнения приложения. По умолчанию - текущая директория.
 	WorkingDirectory(dir string) Interface
 
+	// TempWorkingDirectory Для каждого запуска создаётся новая рабочая директория по шаблону os.MkdirTemp(),
+	// например "build-*" в os.TempDir() или "/var/tmp/build-*", пустой шаблон равен "run-*". Директория
+	// удаляется после завершения процесса, перед удалением содержимое файлов opt.Artifacts сохраняется в Result().
+	// Сохраняются только обычные файлы размером не более opt.ArtifactMax, остальные пропускаются с предупреждением.
+	// Если установлен Chroot(), шаблон указывает путь внутри chroot и директория создаётся внутри chroot, процесс
+	// получает путь относительно директории chroot, а Result.TempDir содержит путь в файловой системе текущего процесса.
+	TempWorkingDirectory(pattern string, opt *TempDirOptions) Interface
+
 	// Environment Переменные окружения, устанавливаемые для приложения. Переменные указываются как "КЛЮЧ=Значение".
 	Environment(env ...string) Interface
 
+	// EnvironmentFile Загрузка переменных окружения процесса из файлов .env, файлы читаются при вызове функции.
+	// Поддерживаются комментарии, префикс "export", значения без кавычек, в одинарных кавычках без подстановок и
+	// экранирования, в двойных кавычках с экранированием \n, \t, \r, \", \\, \$, многострочные значения в кавычках
+	// и подстановки $КЛЮЧ, ${КЛЮЧ} и ${КЛЮЧ:-по умолчанию} из ранее прочитанных переменных и базового окружения.
+	// Приоритет переменных: окружение текущего процесса < файлы .env в порядке загрузки < Environment() <
+	// изменения Setenv(), Unsetenv(), EnvironmentMap() и фильтры. Ошибка чтения доступна через Error().
+	EnvironmentFile(paths ...string) Interface
+
+	// EnvironmentInherit Выбор базового окружения процесса, к которому применяются изменения Setenv(), Unsetenv(),
+	// EnvironmentMap() и фильтры: true - окружение текущего процесса (по умолчанию), false - пустое окружение.
+	// Если переменные окружения установлены через Environment(), базовым окружением являются они.
+	EnvironmentInherit(inherit bool) Interface
+
+	// Setenv Установка переменной окружения процесса поверх базового окружения.
+	// В значении выполняется подстановка ${КЛЮЧ} из окружения, полученного к моменту применения изменения,
+	// например Setenv("PATH", "/opt/bin:${PATH}"). Для записи символа "$" перед "{" используется "$$".
+	Setenv(key string, value string) Interface
+
+	// Unsetenv Удаление переменных окружения процесса из базового окружения и ранее установленных переменных.
+	Unsetenv(keys ...string) Interface
+
+	// EnvironmentMap Установка переменных окружения процесса из карты, аналогично Setenv() для каждой переменной.
+	// Переменные устанавливаются в порядке сортировки имён.
+	EnvironmentMap(env map[string]string) Interface
+
+	// EnvironmentDrop Удаление переменных окружения процесса, имена которых начинаются с одного из префиксов,
+	// например EnvironmentDrop("AWS_").
+	EnvironmentDrop(prefixes ...string) Interface
+
+	// EnvironmentKeep Сохранение только тех переменных окружения процесса, имена которых начинаются с одного из
+	// префиксов, например EnvironmentKeep("LANG", "LC_", "PATH").
+	EnvironmentKeep(prefixes ...string) Interface
+
+	// ResolvedEnvironment Окружение, которое получит процесс при запуске: базовое окружение с применёнными
+	// изменениями, без повторяющихся переменных. Функции OnBeforeStart() могут изменить окружение при запуске.
+	ResolvedEnvironment() (ret []string)
+
 	// Chroot Запускаемое приложение выполняется в режиме chroot в указанной директории.
 	Chroot(dir string) Interface
 
@@ -60,7 +172,88 @@ type Interface interface {
 	// Command Функция возвращает текущую запущенную команду.
 	Command() (ret []string)
 
+	// CommandLine Запущенная команда в виде командной строки с кавычками по правилам POSIX shell,
+	// которую можно скопировать и выполнить в командном интерпретаторе.
+	CommandLine() string
+
+	// Shell Выбор командного интерпретатора для RunShell(), например "sh", "bash" или "/usr/local/bin/zsh".
+	// Пустое значение выбирает "sh". Если strict равен истине, сценарий выполняется в строгом режиме
+	// "set -euo pipefail", pipefail включается, если интерпретатор его поддерживает.
+	Shell(interpreter string, strict bool) Interface
+
+	// ScriptMode Выбор способа передачи сценария интерпретатору в RunScript(), по умолчанию ScriptTempFile.
+	ScriptMode(mode ScriptMode) Interface
+
+	// BufferSize Размер буфера чтения и записи потоков в байтах, по умолчанию 32 KiB.
+	// Значение равное или меньше нуля устанавливает размер по умолчанию. Размер применяется при следующем запуске.
+	BufferSize(size int) Interface
+
+	// ChannelSize Размер буфера каналов STDIN, подписчиков, событий и статистики, по умолчанию 64.
+	// Значение меньше нуля устанавливает размер по умолчанию, значение ноль создаёт каналы без буфера.
+	// Размер применяется к каналам, создаваемым после вызова функции.
+	ChannelSize(size int) Interface
+
+	// ProcessGroup Запускаемое приложение выполняется в собственной группе процессов.
+	// Сигналы приостановки и возобновления выполнения передаются всей группе процессов.
+	ProcessGroup(enable bool) Interface
+
+	// Cgroup Запускаемое приложение помещается в указанную группу cgroup v2 при создании процесса, поэтому все его
+	// потомки так же находятся в группе. На ядрах Linux до версии 5.7 процесс помещается в группу сразу после
+	// запуска, и потомки, созданные до этого момента, могут оказаться вне группы.
+	// Группа должна существовать и быть доступной для записи. Если группа указана, приостановка и возобновление
+	// выполнения процесса выполняются через механизм заморозки cgroup v2.
+	Cgroup(path string) Interface
+
+	// Настройки планирования процесса. Настройки проверяются до запуска и наследуются процессом при создании,
+	// ошибка отменяет запуск и доступна через Error(). Значение oom_score_adj устанавливается сразу после запуска,
+	// ошибка его установки передаётся в журнал и событие EventStarted. Если процесс уже запущен, настройки
+	// применяются немедленно, ошибки применения доступны через Error().
+
+	// Nice Установка значения nice процесса, от -20 (наивысший приоритет) до 19 (наименьший приоритет).
+	Nice(nice int) Interface
+
+	// IOPriority Установка класса и уровня приоритета ввода-вывода процесса, уровень от 0 (наивысший) до 7.
+	IOPriority(class IOPriorityClass, level int) Interface
+
+	// CPUAffinity Установка списка процессоров, на которых может выполняться процесс.
+	CPUAffinity(cpus ...int) Interface
+
+	// SchedPolicy Установка политики планирования процесса.
+	SchedPolicy(policy SchedPolicy) Interface
+
+	// OOMScoreAdj Установка значения oom_score_adj процесса, от -1000 до 1000.
+	OOMScoreAdj(score int) Interface
+
+	// Logger Установка журнала. Значение nil отключает журналирование.
+	// Журнал сохраняется при вызове функции Reset().
+	Logger(logger Logger) Interface
+
+	// События жизненного цикла процесса.
+
+	// OnBeforeStart Регистрация функции, вызываемой перед запуском процесса.
+	// Функция может изменить параметры запуска, или отменить запуск, вернув ошибку. Ошибка становится ошибкой
+	// запуска, доступной через Error(). Функции вызываются в порядке регистрации и сохраняются при вызове Reset().
+	OnBeforeStart(fn func(req *StartRequest) error) Interface
+
+	// OnEvent Регистрация функции, вызываемой при каждом событии жизненного цикла процесса, кроме EventBeforeStart.
+	// Функции вызываются синхронно, в горутине в которой произошло событие, в порядке регистрации, и сохраняются
+	// при вызове Reset(). Функция не должна блокироваться на продолжительное время и не должна синхронно вызывать
+	// Run(), RunWait(), Wait() и Reset(). Событие EventStarted всегда предшествует событиям EventStdinClosed и
+	// EventExited этого же процесса, а функция Wait() возвращает управление после обработки события EventExited.
+	OnEvent(fn func(ev Event)) Interface
+
+	// Events Канал событий жизненного цикла процесса, кроме EventBeforeStart.
+	// События отправляются в канал в порядке их возникновения. Отправка событий не блокирует работу пакета:
+	// если буфер канала заполнен, событие не отправляется в канал. Канал закрывается после прерывания через
+	// контекст, канал сохраняется при вызове Reset(). Если контекст равен nil, возвращается закрытый канал,
+	// так как подписку без контекста невозможно отменить. Подписка с контекстом, который не прерывается,
+	// например context.Background(), действует до конца жизни объекта.
+	Events(ctx context.Context) (ret <-chan Event)
+
 	// Debug Установка режима отладки.
+	// Режим отладки определяет, передаются ли в журнал отладочные сообщения, установленный журнал не заменяется.
+	// Если журнал не установлен, включение режима отладки устанавливает текстовый журнал, записывающий все сообщения
+	// в STDERR. Режим отладки сохраняется при вызове функции Reset().
 	Debug(isDebug bool) Interface
 
 	// Запуск и завершение приложения.
@@ -70,33 +263,85 @@ type Interface interface {
 	// вызову функции Kill().
 	Run(ctx context.Context, args ...string) Interface
 
+	// RunShell Запуск сценария командного интерпретатора без ожидания завершения, аналогично Run().
+	// Аргументы передаются сценарию как позиционные параметры $1, $2 и далее без разбора интерпретатором,
+	// поэтому могут содержать любые символы. Параметр $0 равен имени интерпретатора.
+	RunShell(ctx context.Context, script string, args ...string) Interface
+
+	// RunScript Запуск сценария интерпретатором без ожидания завершения, аналогично Run().
+	// Интерпретатор указывается командной строкой, разбираемой функцией Split(), например "bash", "python3 -u"
+	// или "awk -f": путь к файлу сценария добавляется после неё, а аргументы args передаются после пути.
+	// Если процесс запускается через Sudo(), владельцем файла сценария становится указанный пользователь.
+	RunScript(ctx context.Context, interpreter string, script string, args ...string) Interface
+
 	// RunWait Запуск приложения и ожидание завершения приложения.
 	// Если передан контекст не равный nil, тогда прерывание через контекст завершает работу приложения аналогично
 	// вызову функции Kill().
+	// После завершения приложения использование ресурсов доступно через функции Usage() и Result().
 	RunWait(ctx context.Context, args ...string) (ret *os.ProcessState, err error)
 
 	// Wait Ожидание завершения ранее запущенного приложения.
+	// После завершения приложения использование ресурсов доступно через функции Usage() и Result().
 	Wait() (ret *os.ProcessState, err error)
 
-	// LookPath Выполнение одноимённой утилиты exec.LookPath(), чтобы тыла под рукой,
-	// Ошибку выполнения функции можно получить через Error().
+	// Usage Использование ресурсов последним завершившимся процессом.
+	// Возвращается nil, если процесс не запускался или ещё не завершился.
+	Usage() (ret *Usage)
+
+	// Result Результат выполнения последнего завершившегося процесса: статус, код завершения и использование ресурсов.
+	// Возвращается nil, если процесс не запускался или ещё не завершился.
+	Result() (ret *Result)
+
+	// LookPath Поиск программы так, как её найдёт запускаемый процесс: по переменной PATH окружения процесса,
+	// с учётом рабочей директории и директории chroot. Имя, содержащее "/", не ищется в PATH, а проверяется
+	// относительно рабочей директории. Возвращается путь в файловой системе процесса.
+	// При неудаче ошибка *LookPathError сохраняется и доступна через Error(), успешный поиск ошибку не изменяет.
 	LookPath(proc string) (path string)
 
 	// Pid Возвращает PID процесса. Если процесс не был запущен, возвращается -1.
 	Pid() int
 
+	// Stats Статистика использования ресурсов запущенным процессом, полученная из /proc/<pid>.
+	// Если descendants равен истине, дополнительно собирается статистика всех потомков процесса.
+	Stats(descendants bool) (ret *Stats, err error)
+
+	// StatsCh Канал с периодической статистикой использования ресурсов запущенным процессом.
+	// Если процесс ещё не запущен, отправка статистики начинается после запуска процесса. Статистика отправляется
+	// в канал с указанным интервалом, канал закрывается после завершения процесса, неудачного запуска или
+	// прерывания через контекст. Если канал не успевает читаться, значения пропускаются. Если интервал не
+	// больше нуля, используется интервал по умолчанию 1 секунда.
+	StatsCh(ctx context.Context, interval time.Duration, descendants bool) (ret <-chan *Stats)
+
 	// Signal Отправка сигнала ранее запущенному приложению.
+	// Перед отправкой сигнала завершения приостановленному приложению его выполнение возобновляется, иначе
+	// сигнал не будет обработан до вызова Resume().
 	Signal(sig os.Signal) error
 
 	// Kill Завершение ранее запущенного приложения.
 	Kill() error
 
+	// Pause Приостановка выполнения запущенного приложения.
+	// Если указана группа cgroup, приложение замораживается через cgroup v2, иначе приложению или его группе
+	// процессов отправляется сигнал SIGSTOP.
+	Pause() error
+
+	// Resume Возобновление выполнения приостановленного приложения.
+	Resume() error
+
+	// State Состояние процесса.
+	State() State
+
+	// WaitState Ожидание перехода процесса в указанное состояние.
+	// Функция возвращает управление сразу, если процесс уже находится в указанном состоянии, либо после прерывания
+	// через контекст, возвращая ошибку контекста.
+	WaitState(ctx context.Context, state State) error
+
 	// Release Освобождение всех ресурсов запущенного приложения.
 	// Release необходимо выполнять только в случае если Wait() не работает.
 	Release() error
 
 	// Reset Завершение приложения, если оно было запущено, сброс всех настроек и подготовка пакета для
-	// повторного использования.
+	// повторного использования. Установленный журнал сохраняется.
 	Reset() Interface
 
 	// Error Ошибка, возникшая в функции не возвращающей ошибки.
@@ -0,0 +1,159 @@
+package run
+
+import (
+	"fmt"
+	"math/bits"
+	"runtime"
+)
+
+const (
+	errScheduleNice     = "значение nice %d вне диапазона от -20 до 19"
+	errScheduleIOClass  = "неизвестный класс приоритета ввода-вывода %d"
+	errScheduleIOLevel  = "уровень приоритета ввода-вывода %d вне диапазона от 0 до 7"
+	errScheduleAffinity = "номер процессора %d вне диапазона от 0 до %d"
+	errSchedulePolicy   = "неизвестная политика планирования %d"
+	errScheduleOOM      = "значение oom_score_adj %d вне диапазона от -1000 до 1000"
+)
+
+// IOPriorityClass Класс приоритета ввода-вывода.
+type IOPriorityClass int
+
+const (
+	// IOPriorityNone Класс приоритета ввода-вывода не установлен, приоритет вычисляется из значения nice.
+	IOPriorityNone IOPriorityClass = iota
+
+	// IOPriorityRealTime Класс приоритета ввода-вывода реального времени.
+	IOPriorityRealTime
+
+	// IOPriorityBestEffort Класс приоритета ввода-вывода по умолчанию.
+	IOPriorityBestEffort
+
+	// IOPriorityIdle Ввод-вывод выполняется только при отсутствии ввода-вывода других процессов.
+	IOPriorityIdle
+)
+
+// SchedPolicy Политика планирования процесса.
+type SchedPolicy int
+
+const (
+	// SchedOther Политика планирования по умолчанию.
+	SchedOther SchedPolicy = 0
+
+	// SchedBatch Политика планирования для не интерактивных вычислительных задач.
+	SchedBatch SchedPolicy = 3
+
+	// SchedIdle Политика планирования для задач с очень низким приоритетом.
+	SchedIdle SchedPolicy = 5
+)
+
+// Настройки планирования процесса. Не установленные значения равны nil.
+type schedule struct {
+	nice        *int         // Значение nice.
+	ioClass     *int         // Класс приоритета ввода-вывода.
+	ioLevel     int          // Уровень приоритета ввода-вывода внутри класса.
+	affinity    []int        // Список процессоров, на которых может выполняться процесс.
+	policy      *SchedPolicy // Политика планирования.
+	oomScoreAdj *int         // Значение oom_score_adj.
+}
+
+// Проверка значений настроек планирования до запуска процесса.
+func (s *schedule) check() (err error) {
+	if s == nil {
+		return
+	}
+	switch {
+	case s.nice != nil && (*s.nice < -20 || *s.nice > 19):
+		return fmt.Errorf(errScheduleNice, *s.nice)
+	case s.ioClass != nil && (*s.ioClass < int(IOPriorityNone) || *s.ioClass > int(IOPriorityIdle)):
+		return fmt.Errorf(errScheduleIOClass, *s.ioClass)
+	case s.ioClass != nil && (s.ioLevel < 0 || s.ioLevel > 7):
+		return fmt.Errorf(errScheduleIOLevel, s.ioLevel)
+	case s.policy != nil && *s.policy != SchedOther && *s.policy != SchedBatch && *s.policy != SchedIdle:
+		return fmt.Errorf(errSchedulePolicy, *s.policy)
+	case s.oomScoreAdj != nil && (*s.oomScoreAdj < -1000 || *s.oomScoreAdj > 1000):
+		return fmt.Errorf(errScheduleOOM, *s.oomScoreAdj)
+	}
+	if len(s.affinity) > 0 {
+		limit := scheduleCPULimit()
+		for _, cpu := range s.affinity {
+			if cpu < 0 || cpu >= limit {
+				return fmt.Errorf(errScheduleAffinity, cpu, limit-1)
+			}
+		}
+	}
+
+	return
+}
+
+// Количество номеров процессоров, допустимых в маске процессоров: количество процессоров, поддерживаемых
+// ядром, а если оно неизвестно, количество процессоров runtime.NumCPU(), округлённое до размера слова маски.
+func scheduleCPULimit() (ret int) {
+	if ret = cpuPossible(); ret <= 0 {
+		ret = runtime.NumCPU()
+	}
+
+	return (ret + bits.UintSize - 1) / bits.UintSize * bits.UintSize
+}
+
+// Применение настройки планирования к запущенному процессу.
+// Если процесс не запущен, настройка будет применена при запуске процесса.
+func (run *impl) scheduleSet(fn func(s *schedule), msg string, attrs ...Attr) Interface {
+	const errSchedule = "применение настроек планирования к процессу %d прервано ошибкой: %s"
+	var (
+		one schedule
+		pid int
+		err error
+	)
+
+	run.fieldSync.Lock()
+	if run.schedule == nil {
+		run.schedule = new(schedule)
+	}
+	fn(run.schedule)
+	run.fieldSync.Unlock()
+	run.log(LevelDebug, msg, attrs...)
+	if pid = run.Pid(); pid < 0 {
+		return run
+	}
+	fn(&one)
+	if err = scheduleApply(pid, &one); err != nil {
+		run.errSet(fmt.Errorf(errSchedule, pid, err))
+	}
+
+	return run
+}
+
+// Nice Установка значения nice процесса, от -20 (наивысший приоритет) до 19 (наименьший приоритет).
+func (run *impl) Nice(nice int) Interface {
+	const msgNice = "config.nice"
+	return run.scheduleSet(func(s *schedule) { s.nice = &nice }, msgNice, attr("nice", nice))
+}
+
+// IOPriority Установка класса и уровня приоритета ввода-вывода процесса, уровень от 0 (наивысший) до 7.
+func (run *impl) IOPriority(class IOPriorityClass, level int) Interface {
+	const msgIOPriority = "config.io_priority"
+	var c = int(class)
+	return run.scheduleSet(
+		func(s *schedule) { s.ioClass, s.ioLevel = &c, level },
+		msgIOPriority, attr("class", class), attr("level", level),
+	)
+}
+
+// CPUAffinity Установка списка процессоров, на которых может выполняться процесс.
+func (run *impl) CPUAffinity(cpus ...int) Interface {
+	const msgAffinity = "config.cpu_affinity"
+	var affinity = append(make([]int, 0, len(cpus)), cpus...)
+	return run.scheduleSet(func(s *schedule) { s.affinity = affinity }, msgAffinity, attr("cpus", affinity))
+}
+
+// SchedPolicy Установка политики планирования процесса.
+func (run *impl) SchedPolicy(policy SchedPolicy) Interface {
+	const msgPolicy = "config.sched_policy"
+	return run.scheduleSet(func(s *schedule) { s.policy = &policy }, msgPolicy, attr("policy", policy))
+}
+
+// OOMScoreAdj Установка значения oom_score_adj процесса, от -1000 до 1000.
+func (run *impl) OOMScoreAdj(score int) Interface {
+	const msgOOM = "config.oom_score_adj"
+	return run.scheduleSet(func(s *schedule) { s.oomScoreAdj = &score }, msgOOM, attr("score", score))
+}
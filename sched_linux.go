@@ -0,0 +1,210 @@
+//go:build linux
+
+package run
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	ioprioWhoProcess = 1                                  // Значение IOPRIO_WHO_PROCESS системного вызова ioprio_set.
+	ioprioClassShift = 13                                 // Смещение класса в значении приоритета ввода-вывода.
+	cpuPossiblePath  = "/sys/devices/system/cpu/possible" // Список процессоров, поддерживаемых ядром.
+)
+
+// Применение настроек планирования к процессу и всем его потокам.
+func scheduleApply(pid int, s *schedule) (err error) {
+	const errTasks = "получение списка потоков процесса прервано ошибкой: %s"
+	var (
+		entries []os.DirEntry
+		tids    []int
+	)
+
+	if s == nil {
+		return
+	}
+	// Настройки, изменяемые во время выполнения процесса, проверяются так же, как при запуске.
+	if err = s.check(); err != nil {
+		return
+	}
+	// Большинство настроек планирования в Linux применяется к отдельным потокам, поэтому они применяются
+	// к каждому потоку процесса.
+	if entries, err = os.ReadDir(procPath(pid, "task")); err != nil {
+		return fmt.Errorf(errTasks, err)
+	}
+	for _, entry := range entries {
+		if tid := atoi(entry.Name()); tid > 0 {
+			tids = append(tids, tid)
+		}
+	}
+	for _, tid := range tids {
+		if err = scheduleThread(tid, s); err != nil {
+			return
+		}
+	}
+	err = scheduleOOM(pid, s)
+
+	return
+}
+
+// Запуск процесса с настройками планирования. Настройки потока устанавливаются потоку, выполняющему запуск,
+// и наследуются процессом при создании, поэтому процесс и все его потоки выполняются с ними с первой инструкции,
+// а ошибка настройки возвращается до запуска процесса. Значение oom_score_adj общее для всех потоков текущего
+// процесса, поэтому оно устанавливается после запуска функцией scheduleOOM().
+func scheduleStart(name string, argv []string, attr *os.ProcAttr, s *schedule) (ret *os.Process, err error) {
+	var done chan struct{}
+
+	if s == nil || (s.policy == nil && s.nice == nil && s.ioClass == nil && len(s.affinity) == 0) {
+		return os.StartProcess(name, argv, attr)
+	}
+	done = make(chan struct{})
+	go func() {
+		defer close(done)
+		// Поток не освобождается, поэтому после завершения горутины он завершается вместе с изменёнными
+		// настройками и не используется другими горутинами.
+		runtime.LockOSThread()
+		if err = scheduleThread(syscall.Gettid(), s); err != nil {
+			return
+		}
+		ret, err = os.StartProcess(name, argv, attr)
+	}()
+	<-done
+
+	return
+}
+
+// Применение настроек планирования к потоку.
+func scheduleThread(tid int, s *schedule) (err error) {
+	const (
+		errPolicy   = "установка политики планирования прервана ошибкой: %s"
+		errNice     = "установка значения nice прервана ошибкой: %s"
+		errIOPrio   = "установка приоритета ввода-вывода прервана ошибкой: %s"
+		errAffinity = "установка процессоров выполнения прервана ошибкой: %s"
+	)
+
+	if s.policy != nil {
+		if err = schedSetScheduler(tid, *s.policy); err != nil {
+			return fmt.Errorf(errPolicy, err)
+		}
+	}
+	if s.nice != nil {
+		if err = syscall.Setpriority(syscall.PRIO_PROCESS, tid, *s.nice); err != nil {
+			return fmt.Errorf(errNice, err)
+		}
+	}
+	if s.ioClass != nil {
+		if err = ioprioSet(tid, *s.ioClass, s.ioLevel); err != nil {
+			return fmt.Errorf(errIOPrio, err)
+		}
+	}
+	if len(s.affinity) > 0 {
+		if err = schedSetAffinity(tid, s.affinity); err != nil {
+			return fmt.Errorf(errAffinity, err)
+		}
+	}
+
+	return
+}
+
+// Установка значения oom_score_adj процесса.
+func scheduleOOM(pid int, s *schedule) (err error) {
+	const errOOMScore = "установка значения oom_score_adj прервана ошибкой: %s"
+
+	if s == nil || s.oomScoreAdj == nil {
+		return
+	}
+	if err = os.WriteFile(procPath(pid, "oom_score_adj"), []byte(strconv.Itoa(*s.oomScoreAdj)), 0); err != nil {
+		err = fmt.Errorf(errOOMScore, err)
+	}
+
+	return
+}
+
+// Системный вызов sched_setscheduler.
+func schedSetScheduler(tid int, policy SchedPolicy) (err error) {
+	var (
+		param = struct{ priority int32 }{}
+		errno syscall.Errno
+	)
+
+	if _, _, errno = syscall.Syscall(
+		syscall.SYS_SCHED_SETSCHEDULER, uintptr(tid), uintptr(policy), uintptr(unsafe.Pointer(&param)),
+	); errno != 0 {
+		err = errno
+	}
+
+	return
+}
+
+// Системный вызов ioprio_set.
+func ioprioSet(tid int, class int, level int) (err error) {
+	var errno syscall.Errno
+
+	if _, _, errno = syscall.Syscall(
+		syscall.SYS_IOPRIO_SET, ioprioWhoProcess, uintptr(tid), uintptr(class<<ioprioClassShift|level),
+	); errno != 0 {
+		err = errno
+	}
+
+	return
+}
+
+// Системный вызов sched_setaffinity.
+func schedSetAffinity(tid int, cpus []int) (err error) {
+	const bits = int(unsafe.Sizeof(uintptr(0)) * 8)
+	var (
+		mask  []uintptr
+		errno syscall.Errno
+		last  int
+	)
+
+	for _, cpu := range cpus {
+		if cpu > last {
+			last = cpu
+		}
+	}
+	mask = make([]uintptr, last/bits+1)
+	for _, cpu := range cpus {
+		if cpu >= 0 {
+			mask[cpu/bits] |= 1 << uint(cpu%bits)
+		}
+	}
+	if _, _, errno = syscall.RawSyscall(
+		syscall.SYS_SCHED_SETAFFINITY, uintptr(tid), uintptr(len(mask))*unsafe.Sizeof(mask[0]),
+		uintptr(unsafe.Pointer(&mask[0])),
+	); errno != 0 {
+		err = errno
+	}
+
+	return
+}
+
+// Количество процессоров, поддерживаемых ядром, по списку процессоров вида "0-7" или "0,2-3",
+// 0 - если список недоступен.
+func cpuPossible() (ret int) {
+	var (
+		buf  []byte
+		err  error
+		text string
+		last int
+	)
+
+	if buf, err = os.ReadFile(cpuPossiblePath); err != nil {
+		return
+	}
+	text = strings.TrimSpace(string(buf))
+	if n := strings.LastIndexAny(text, ",-"); n >= 0 {
+		text = text[n+1:]
+	}
+	if last, err = strconv.Atoi(text); err == nil && last >= 0 {
+		ret = last + 1
+	}
+
+	return
+}
@@ -0,0 +1,36 @@
+//go:build !linux
+
+package run
+
+import (
+	"errors"
+	"os"
+)
+
+// Применение настроек планирования к процессу.
+func scheduleApply(_ int, s *schedule) (err error) {
+	if s != nil {
+		err = errors.New(errUnsupported)
+	}
+	return
+}
+
+// Запуск процесса с настройками планирования.
+func scheduleStart(name string, argv []string, attr *os.ProcAttr, s *schedule) (ret *os.Process, err error) {
+	if s != nil {
+		err = errors.New(errUnsupported)
+		return
+	}
+	return os.StartProcess(name, argv, attr)
+}
+
+// Установка значения oom_score_adj процесса.
+func scheduleOOM(_ int, s *schedule) (err error) {
+	if s != nil && s.oomScoreAdj != nil {
+		err = errors.New(errUnsupported)
+	}
+	return
+}
+
+// Количество процессоров, поддерживаемых ядром, неизвестно.
+func cpuPossible() int { return 0 }
@@ -0,0 +1,53 @@
+package run
+
+import (
+	"context"
+	"testing"
+)
+
+func TestScheduleCheck(t *testing.T) {
+	var (
+		limit = scheduleCPULimit()
+		bad   = 20
+		class = int(IOPriorityIdle) + 1
+	)
+
+	tests := []struct {
+		name string
+		s    *schedule
+		ok   bool
+	}{
+		{"настройки не установлены", nil, true},
+		{"процессор 0", &schedule{affinity: []int{0}}, true},
+		{"последний процессор маски", &schedule{affinity: []int{0, limit - 1}}, true},
+		{"отрицательный номер процессора", &schedule{affinity: []int{0, -1}}, false},
+		{"номер процессора за пределами маски", &schedule{affinity: []int{limit}}, false},
+		{"очень большой номер процессора", &schedule{affinity: []int{1 << 40}}, false},
+		{"значение nice", &schedule{nice: &bad}, false},
+		{"класс приоритета ввода-вывода", &schedule{ioClass: &class}, false},
+	}
+	if limit <= 0 || limit%64 != 0 && limit%32 != 0 {
+		t.Errorf("количество номеров процессоров %d не кратно размеру слова маски", limit)
+	}
+	for _, tt := range tests {
+		if err := tt.s.check(); (err == nil) != tt.ok {
+			t.Errorf("%s: check() = %v", tt.name, err)
+		}
+	}
+}
+
+// Настройки планирования, изменяемые во время выполнения процесса, проверяются до применения.
+func TestScheduleRuntimeCheck(t *testing.T) {
+	var run = New()
+
+	if err := run.Run(context.Background(), "sleep", "30").Error(); err != nil {
+		t.Fatalf("запуск процесса прерван ошибкой: %v", err)
+	}
+	defer func() { _ = run.Kill(); _, _ = run.Wait() }()
+	if err := run.CPUAffinity(1 << 40).Error(); err == nil {
+		t.Errorf("недопустимый номер процессора применён к процессу без ошибки")
+	}
+	if err := run.Nice(100).Error(); err == nil {
+		t.Errorf("недопустимое значение nice применено к процессу без ошибки")
+	}
+}
@@ -0,0 +1,170 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+const (
+	scriptPattern = "run-script-*" // Шаблон имени временной директории и файла сценария.
+	scriptName    = "script"       // Имя файла сценария во временной директории.
+	scriptPerm    = 0700           // Права доступа файла сценария.
+	scriptFdPerm  = 0400           // Права доступа файла сценария, передаваемого через файловый дескриптор.
+	scriptFdPath  = "/dev/fd/"     // Путь к файловым дескрипторам процесса.
+)
+
+const (
+	errScriptInterp = "не указан интерпретатор сценария"
+	errScriptSplit  = "разбор командной строки интерпретатора %q прерван ошибкой: %s"
+	errScriptCreate = "создание файла сценария прервано ошибкой: %s"
+)
+
+// ScriptMode Способ передачи сценария интерпретатору в RunScript().
+type ScriptMode int
+
+const (
+	// ScriptTempFile Сценарий записывается в файл с правами 0700 в отдельной временной директории с правами 0700,
+	// директория удаляется после завершения процесса, в том числе принудительного. Директория создаётся в
+	// os.TempDir() текущего процесса, поэтому в chroot следует использовать ScriptDescriptor.
+	ScriptTempFile ScriptMode = iota
+
+	// ScriptDescriptor Сценарий записывается во временный файл, который удаляется до запуска процесса, и
+	// передаётся процессу как файловый дескриптор, интерпретатор читает его по пути /dev/fd/N.
+	// Сценарий не остаётся на диске, даже если завершится текущий процесс. Путь /dev/fd должен быть доступен
+	// процессу, в том числе в chroot.
+	ScriptDescriptor
+)
+
+// ScriptMode Выбор способа передачи сценария интерпретатору в RunScript(), по умолчанию ScriptTempFile.
+func (run *impl) ScriptMode(mode ScriptMode) Interface {
+	const msgMode = "config.script.mode"
+
+	run.fieldSync.Lock()
+	run.scriptMode = mode
+	run.fieldSync.Unlock()
+	run.log(LevelDebug, msgMode, attr("mode", int(mode)))
+
+	return run
+}
+
+// RunScript Запуск сценария интерпретатором без ожидания завершения, аналогично Run().
+// Интерпретатор указывается командной строкой, разбираемой функцией Split(), например "bash", "python3 -u"
+// или "awk -f": путь к файлу сценария добавляется после неё, а аргументы args передаются после пути.
+// Если процесс запускается через Sudo(), владельцем файла сценария становится указанный пользователь.
+func (run *impl) RunScript(ctx context.Context, interpreter string, script string, args ...string) Interface {
+	var (
+		err     error
+		argv    []string
+		mode    ScriptMode
+		cred    *Credential
+		fh      *os.File
+		path    string
+		cleanup func()
+	)
+
+	if argv, err = Split(interpreter); err != nil || len(argv) == 0 {
+		if err == nil {
+			err = errors.New(errScriptInterp)
+		} else {
+			err = fmt.Errorf(errScriptSplit, interpreter, err)
+		}
+		run.errSet(err)
+		return run
+	}
+	run.fieldSync.RLock()
+	mode = run.scriptMode
+	if run.attributes.Sys != nil && run.attributes.Sys.Credential != nil {
+		cred = &Credential{UserID: run.attributes.Sys.Credential.Uid, GroupID: run.attributes.Sys.Credential.Gid}
+	}
+	run.fieldSync.RUnlock()
+	switch mode {
+	case ScriptDescriptor:
+		if fh, err = scriptDescriptor(script, cred); err != nil {
+			run.errSet(fmt.Errorf(errScriptCreate, err))
+			return run
+		}
+		// Процесс получает копию файлового дескриптора, копия пакета закрывается после запуска.
+		defer func() { _ = fh.Close() }()
+		run.fieldSync.Lock()
+		run.scriptFile = fh
+		path = scriptFdPath + strconv.Itoa(listenFdsStart+len(run.sockets)+len(run.extraFiles))
+		run.fieldSync.Unlock()
+		defer func() {
+			run.fieldSync.Lock()
+			run.scriptFile = nil
+			run.fieldSync.Unlock()
+		}()
+	default:
+		if path, cleanup, err = scriptTempFile(script, cred); err != nil {
+			run.errSet(fmt.Errorf(errScriptCreate, err))
+			return run
+		}
+	}
+	run.Run(ctx, append(append(argv, path), args...)...)
+	if cleanup == nil {
+		return run
+	}
+	// Временная директория удаляется после завершения процесса, или сразу, если процесс не был запущен.
+	if run.processGet() == nil {
+		cleanup()
+		return run
+	}
+	go func() {
+		_, _ = run.Wait()
+		cleanup()
+	}()
+
+	return run
+}
+
+// Запись сценария в файл во временной директории с правами доступа только для владельца.
+// Возвращается путь к файлу и функция удаления временной директории.
+func scriptTempFile(script string, cred *Credential) (path string, cleanup func(), err error) {
+	var dir string
+
+	if dir, err = os.MkdirTemp("", scriptPattern); err != nil {
+		return
+	}
+	cleanup = func() { _ = os.RemoveAll(dir) }
+	path = filepath.Join(dir, scriptName)
+	if err = os.WriteFile(path, []byte(script), scriptPerm); err == nil && cred != nil {
+		if err = os.Chown(dir, int(cred.UserID), int(cred.GroupID)); err == nil {
+			err = os.Chown(path, int(cred.UserID), int(cred.GroupID))
+		}
+	}
+	if err != nil {
+		cleanup()
+		path, cleanup = "", nil
+	}
+
+	return
+}
+
+// Запись сценария во временный файл, удалённый из файловой системы. Файл доступен только через
+// возвращённый открытый файл, позиция чтения установлена в начало файла.
+func scriptDescriptor(script string, cred *Credential) (ret *os.File, err error) {
+	if ret, err = os.CreateTemp("", scriptPattern); err != nil {
+		return
+	}
+	_ = os.Remove(ret.Name())
+	if _, err = ret.WriteString(script); err == nil {
+		_, err = ret.Seek(0, 0)
+	}
+	// Интерпретатор открывает /dev/fd/N повторно, поэтому права доступа проверяются для пользователя процесса.
+	if err == nil {
+		err = ret.Chmod(scriptFdPerm)
+	}
+	if err == nil && cred != nil {
+		err = ret.Chown(int(cred.UserID), int(cred.GroupID))
+	}
+	if err != nil {
+		_ = ret.Close()
+		ret = nil
+	}
+
+	return
+}
@@ -0,0 +1,147 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	shellDefault = "sh" // Командный интерпретатор по умолчанию.
+	// Строгий режим: завершение при ошибке и при обращении к неустановленной переменной, а также pipefail,
+	// если интерпретатор его поддерживает, так как pipefail отсутствует в старых версиях POSIX sh.
+	shellStrict = "set -eu\n(set -o pipefail) 2>/dev/null && set -o pipefail\n"
+	// Символы, не требующие кавычек в командной строке.
+	shellSafe = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-+=%@:,./"
+)
+
+const (
+	errSplitQuote  = "не закрыта кавычка"
+	errSplitEscape = "экранирующий символ в конце строки"
+)
+
+// Shell Выбор командного интерпретатора для RunShell(), например "sh", "bash" или "/usr/local/bin/zsh".
+// Пустое значение выбирает "sh". Если strict равен истине, сценарий выполняется в строгом режиме
+// "set -euo pipefail", pipefail включается, если интерпретатор его поддерживает.
+func (run *impl) Shell(interpreter string, strict bool) Interface {
+	const msgShell = "config.shell"
+
+	if interpreter == "" {
+		interpreter = shellDefault
+	}
+	run.fieldSync.Lock()
+	run.shell, run.shellStrict = interpreter, strict
+	run.fieldSync.Unlock()
+	run.log(LevelDebug, msgShell, attr("interpreter", interpreter), attr("strict", strict))
+
+	return run
+}
+
+// RunShell Запуск сценария командного интерпретатора без ожидания завершения, аналогично Run().
+// Аргументы передаются сценарию как позиционные параметры $1, $2 и далее без разбора интерпретатором,
+// поэтому могут содержать любые символы. Параметр $0 равен имени интерпретатора.
+func (run *impl) RunShell(ctx context.Context, script string, args ...string) Interface {
+	var interpreter string
+
+	run.fieldSync.RLock()
+	interpreter = run.shell
+	if run.shellStrict {
+		script = shellStrict + script
+	}
+	run.fieldSync.RUnlock()
+
+	return run.Run(ctx, append([]string{interpreter, "-c", script, filepath.Base(interpreter)}, args...)...)
+}
+
+// CommandLine Запущенная команда в виде командной строки с кавычками по правилам POSIX shell,
+// которую можно скопировать и выполнить в командном интерпретаторе.
+func (run *impl) CommandLine() string { return QuoteCommand(run.Command()...) }
+
+// Quote Заключение строки в кавычки по правилам POSIX shell, строка без специальных символов не изменяется.
+func Quote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	if strings.Trim(s, shellSafe) == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// QuoteCommand Командная строка из программы и аргументов с кавычками по правилам POSIX shell.
+func QuoteCommand(args ...string) string {
+	var quoted = make([]string, 0, len(args))
+
+	for n, arg := range args {
+		// Первое слово со знаком "=" интерпретатор считает присваиванием переменной, а не программой.
+		if n == 0 && strings.Contains(arg, "=") {
+			quoted = append(quoted, "'"+strings.ReplaceAll(arg, "'", `'\''`)+"'")
+			continue
+		}
+		quoted = append(quoted, Quote(arg))
+	}
+
+	return strings.Join(quoted, " ")
+}
+
+// Split Разделение командной строки на программу и аргументы по правилам POSIX shell: аргументы разделяются
+// пробельными символами, строка в одинарных кавычках не изменяется, в двойных кавычках символ "\"
+// экранирует только символы $, `, ", \ и перевод строки, вне кавычек "\" экранирует любой символ.
+// Подстановки переменных и команд не выполняются, символы $ и ` сохраняются как есть.
+func Split(line string) (ret []string, err error) {
+	var (
+		word  strings.Builder
+		inArg bool
+		end   int
+	)
+
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; c {
+		case ' ', '\t', '\n', '\r':
+			if inArg {
+				ret, inArg = append(ret, word.String()), false
+				word.Reset()
+			}
+		case '\\':
+			if i+1 == len(line) {
+				return nil, errors.New(errSplitEscape)
+			}
+			// Экранированный перевод строки является продолжением строки.
+			if i++; line[i] != '\n' {
+				word.WriteByte(line[i])
+				inArg = true
+			}
+		case '\'':
+			if end = strings.IndexByte(line[i+1:], '\''); end < 0 {
+				return nil, errors.New(errSplitQuote)
+			}
+			word.WriteString(line[i+1 : i+1+end])
+			i, inArg = i+1+end, true
+		case '"':
+			inArg = true
+			for i++; ; i++ {
+				if i == len(line) {
+					return nil, errors.New(errSplitQuote)
+				}
+				if line[i] == '"' {
+					break
+				}
+				if line[i] == '\\' && i+1 < len(line) && strings.IndexByte("$`\"\\\n", line[i+1]) >= 0 {
+					if i++; line[i] == '\n' {
+						continue
+					}
+				}
+				word.WriteByte(line[i])
+			}
+		default:
+			word.WriteByte(c)
+			inArg = true
+		}
+	}
+	if inArg {
+		ret = append(ret, word.String())
+	}
+
+	return
+}
@@ -0,0 +1,123 @@
+package run
+
+import (
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Наборы аргументов для проверки кавычек и разбора командной строки.
+var testShellArgs = [][]string{
+	{"ls"},
+	{"ls", "-la", "/tmp"},
+	{"echo", ""},
+	{"echo", "", ""},
+	{"echo", "two words", "tab\there"},
+	{"echo", "it's"},
+	{"echo", "'"},
+	{"echo", "''"},
+	{"echo", `'\''`},
+	{"echo", `"double" quotes`},
+	{"echo", `back\slash`, `\`},
+	{"echo", "$HOME", "${PATH}", "`id`", "$(id)"},
+	{"echo", "line 1\nline 2", "\n"},
+	{"echo", "*", "?", "[a]", "~", "#", ";", "&", "|", ">", "<", "(", ")", "!"},
+	{"A=1", "B=2"},
+	{"it's=1"},
+	{"юникод", "строка с пробелом"},
+}
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		in  string
+		out string
+	}{
+		{"", "''"},
+		{"plain", "plain"},
+		{"/usr/bin/env", "/usr/bin/env"},
+		{"a=b,c:d@e%f+g", "a=b,c:d@e%f+g"},
+		{"two words", "'two words'"},
+		{"it's", `'it'\''s'`},
+		{"'", `''\'''`},
+		{"$HOME", "'$HOME'"},
+		{"a\nb", "'a\nb'"},
+	}
+	for _, tt := range tests {
+		if out := Quote(tt.in); out != tt.out {
+			t.Errorf("Quote(%q) = %q, ожидается %q", tt.in, out, tt.out)
+		}
+	}
+	if out := QuoteCommand("A=1", "B=2"); out != "'A=1' B=2" {
+		t.Errorf("присваивание в начале командной строки не заключено в кавычки: %q", out)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		out  []string
+		err  string
+	}{
+		{name: "пустая строка", in: "", out: nil},
+		{name: "только пробелы", in: " \t\n ", out: nil},
+		{name: "разделители", in: "  a \t b\nc  ", out: []string{"a", "b", "c"}},
+		{name: "одинарные кавычки", in: `'a b' 'c\d' '$x'`, out: []string{"a b", `c\d`, "$x"}},
+		{name: "пустые кавычки", in: `'' ""`, out: []string{"", ""}},
+		{name: "склейка частей слова", in: `a'b'"c"\ d`, out: []string{"abc d"}},
+		{name: "экранирование одинарной кавычки", in: `'it'\''s'`, out: []string{"it's"}},
+		{name: "экранирование в двойных кавычках", in: `"\$ \` + "`" + ` \" \\ \n"`, out: []string{"$ ` \" \\ \\n"}},
+		{name: "перевод строки в кавычках", in: "'a\nb' \"c\nd\"", out: []string{"a\nb", "c\nd"}},
+		{name: "продолжение строки", in: "a\\\nb \"c\\\nd\"", out: []string{"ab", "cd"}},
+		{name: "подстановки не выполняются", in: "$HOME ${PATH} `id`", out: []string{"$HOME", "${PATH}", "`id`"}},
+		{name: "не закрыта одинарная кавычка", in: "a 'b", err: errSplitQuote},
+		{name: "не закрыта двойная кавычка", in: `a "b\"`, err: errSplitQuote},
+		{name: "экранирующий символ в конце", in: `a\`, err: errSplitEscape},
+	}
+	for _, tt := range tests {
+		out, err := Split(tt.in)
+		switch {
+		case tt.err != "" && (err == nil || err.Error() != tt.err):
+			t.Errorf("%s: Split(%q) ошибка %v, ожидается %q", tt.name, tt.in, err, tt.err)
+		case tt.err == "" && err != nil:
+			t.Errorf("%s: Split(%q) прервано ошибкой: %v", tt.name, tt.in, err)
+		case tt.err == "" && !reflect.DeepEqual(out, tt.out):
+			t.Errorf("%s: Split(%q) = %q, ожидается %q", tt.name, tt.in, out, tt.out)
+		}
+	}
+}
+
+// Командная строка, сформированная QuoteCommand(), разбирается функцией Split() в исходные аргументы.
+func TestSplitQuoteCommand(t *testing.T) {
+	for _, args := range testShellArgs {
+		line := QuoteCommand(args...)
+		out, err := Split(line)
+		if err != nil {
+			t.Errorf("Split(%q) прервано ошибкой: %v", line, err)
+			continue
+		}
+		if !reflect.DeepEqual(out, args) {
+			t.Errorf("Split(QuoteCommand(%q)) = %q", args, out)
+		}
+	}
+}
+
+// Командный интерпретатор получает из командной строки, сформированной QuoteCommand(), исходные аргументы.
+func TestQuoteCommandShell(t *testing.T) {
+	if _, err := exec.LookPath(shellDefault); err != nil {
+		t.Skipf("командный интерпретатор %q не найден", shellDefault)
+	}
+	for _, args := range testShellArgs {
+		// Первый аргумент передаётся как аргумент printf, так как программы с такими именами не существует.
+		line := `printf '%s\0' ` + QuoteCommand(args...)
+		out, err := exec.Command(shellDefault, "-c", line).Output()
+		if err != nil {
+			t.Errorf("выполнение %q прервано ошибкой: %v", line, err)
+			continue
+		}
+		if got := strings.Split(strings.TrimSuffix(string(out), "\x00"), "\x00"); !reflect.DeepEqual(got, args) {
+			t.Errorf("интерпретатор получил аргументы %q, ожидается %q", got, args)
+		}
+	}
+}
@@ -0,0 +1,340 @@
+package run
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// RestartMode Условие перезапуска процесса.
+type RestartMode string
+
+const (
+	// RestartNever Процесс не перезапускается.
+	RestartNever RestartMode = "never"
+
+	// RestartOnFailure Процесс перезапускается, если он завершился с ошибкой или с кодом завершения не равным нулю.
+	RestartOnFailure RestartMode = "on-failure"
+
+	// RestartAlways Процесс перезапускается после любого завершения.
+	RestartAlways RestartMode = "always"
+)
+
+// Spec Описание запуска команды, не связанное с запущенным процессом.
+// Описание является значением: пакет не изменяет переданное описание, а каждый запуск создаёт новый
+// независимый объект процесса, поэтому одно описание может быть запущено многократно, в том числе одновременно.
+// Для получения независимой копии описания, включая срезы и указатели, используется Clone().
+type Spec struct {
+	Args           []string      // Программа и аргументы.
+	Env            []string      // Переменные окружения "КЛЮЧ=Значение", nil - окружение текущего процесса.
+	Dir            string        // Рабочая директория.
+	Chroot         string        // Директория chroot.
+	Credential     *Credential   // Пользователь и группы, от имени которых запускается процесс.
+	ProcessGroup   bool          // Запуск процесса в собственной группе процессов.
+	Cgroup         string        // Путь к группе cgroup v2.
+	Limits         Limits        // Настройки планирования и приоритетов процесса.
+	StdIn          string        // Путь к файлу, из которого процесс получает данные потока STDIN.
+	StdOut         *Redirect     // Перенаправление потока STDOUT в файл.
+	StdErr         *Redirect     // Перенаправление потока STDERR в файл.
+	StdErrToStdOut bool          // Перенаправление потока STDERR в поток STDOUT.
+	BufferSize     int           // Размер буфера чтения и записи потоков в байтах, 0 - размер по умолчанию.
+	ChannelSize    int           // Размер буфера каналов, 0 - размер по умолчанию.
+	Timeout        time.Duration // Максимальное время выполнения процесса, 0 - без ограничения.
+	Restart        RestartPolicy // Политика перезапуска процесса, применяется функцией RunWait().
+}
+
+// RestartPolicy Политика перезапуска процесса.
+type RestartPolicy struct {
+	Mode        RestartMode   // Условие перезапуска, пустое значение равно RestartNever.
+	MaxRestarts int           // Максимальное количество перезапусков, 0 - без ограничения.
+	Delay       time.Duration // Задержка перед перезапуском.
+}
+
+// Credential Пользователь и группы, от имени которых запускается процесс.
+type Credential struct {
+	UserID      uint32   // Идентификатор пользователя.
+	GroupID     uint32   // Идентификатор группы.
+	NoSetGroups bool     // Не устанавливать дополнительные группы.
+	Groups      []uint32 // Идентификаторы дополнительных групп.
+}
+
+// Limits Настройки планирования и приоритетов процесса. Не установленные значения равны nil.
+type Limits struct {
+	Nice        *int         // Значение nice.
+	IOPriority  *IOPriority  // Приоритет ввода-вывода.
+	CPUAffinity []int        // Список процессоров, на которых может выполняться процесс.
+	SchedPolicy *SchedPolicy // Политика планирования.
+	OOMScoreAdj *int         // Значение oom_score_adj.
+}
+
+// IOPriority Приоритет ввода-вывода.
+type IOPriority struct {
+	Class IOPriorityClass // Класс приоритета.
+	Level int             // Уровень приоритета внутри класса, от 0 (наивысший) до 7.
+}
+
+// Redirect Перенаправление стандартного потока процесса в файл.
+type Redirect struct {
+	Path  string      // Путь к файлу, os.DevNull для отбрасывания данных.
+	Flags int         // Флаги открытия файла, 0 - os.O_WRONLY|os.O_CREATE|os.O_TRUNC.
+	Perm  os.FileMode // Права доступа создаваемого файла.
+}
+
+// NewSpec Конструктор описания запуска команды.
+func NewSpec(args ...string) Spec { return Spec{Args: append([]string(nil), args...)} }
+
+// Clone Независимая копия описания запуска.
+func (s Spec) Clone() (ret Spec) {
+	ret = s
+	ret.Args = cloneSlice(s.Args)
+	ret.Env = cloneSlice(s.Env)
+	if s.Credential != nil {
+		cred := *s.Credential
+		cred.Groups = cloneSlice(s.Credential.Groups)
+		ret.Credential = &cred
+	}
+	ret.Limits = s.Limits.clone()
+	if s.StdOut != nil {
+		rdr := *s.StdOut
+		ret.StdOut = &rdr
+	}
+	if s.StdErr != nil {
+		rdr := *s.StdErr
+		ret.StdErr = &rdr
+	}
+
+	return
+}
+
+// Equal Сравнение описаний запуска по значению.
+// Значение nil и пустой список переменных окружения различаются, так как означают разное окружение процесса.
+func (s Spec) Equal(o Spec) bool {
+	switch {
+	case !equalSlice(s.Args, o.Args),
+		(s.Env == nil) != (o.Env == nil) || !equalSlice(s.Env, o.Env),
+		s.Dir != o.Dir,
+		s.Chroot != o.Chroot,
+		!s.Credential.equal(o.Credential),
+		s.ProcessGroup != o.ProcessGroup,
+		s.Cgroup != o.Cgroup,
+		!s.Limits.equal(o.Limits),
+		s.StdIn != o.StdIn,
+		!equalPtr(s.StdOut, o.StdOut),
+		!equalPtr(s.StdErr, o.StdErr),
+		s.StdErrToStdOut != o.StdErrToStdOut,
+		s.BufferSize != o.BufferSize,
+		s.ChannelSize != o.ChannelSize,
+		s.Timeout != o.Timeout,
+		s.Restart != o.Restart:
+		return false
+	default:
+		return true
+	}
+}
+
+// New Создание нового объекта процесса, настроенного в соответствии с описанием запуска.
+// Процесс не запускается, запуск выполняется функцией Run() созданного объекта с аргументами описания.
+func (s Spec) New() Interface {
+	var run = New()
+
+	s = s.Clone()
+	if s.Dir != "" {
+		run.WorkingDirectory(s.Dir)
+	}
+	if s.Env != nil {
+		run.Environment(s.Env...)
+	}
+	if s.Chroot != "" {
+		run.Chroot(s.Chroot)
+	}
+	if s.Credential != nil {
+		run.Sudo(s.Credential.UserID, s.Credential.GroupID, s.Credential.NoSetGroups, s.Credential.Groups...)
+	}
+	if s.ProcessGroup {
+		run.ProcessGroup(true)
+	}
+	if s.Cgroup != "" {
+		run.Cgroup(s.Cgroup)
+	}
+	s.Limits.apply(run)
+	if s.StdIn != "" {
+		run.StdInFile(s.StdIn)
+	}
+	if s.StdOut != nil {
+		run.StdOutFile(s.StdOut.Path, s.StdOut.Flags, s.StdOut.Perm)
+	}
+	if s.StdErr != nil {
+		run.StdErrFile(s.StdErr.Path, s.StdErr.Flags, s.StdErr.Perm)
+	}
+	if s.StdErrToStdOut {
+		run.StdErrToStdOut()
+	}
+	if s.BufferSize > 0 {
+		run.BufferSize(s.BufferSize)
+	}
+	if s.ChannelSize > 0 {
+		run.ChannelSize(s.ChannelSize)
+	}
+
+	return run
+}
+
+// Start Создание нового объекта процесса и запуск процесса без ожидания его завершения.
+// Если указано максимальное время выполнения, процесс завершается по его истечении аналогично вызову Kill().
+// Ошибку запуска можно получить через Error() возвращённого объекта.
+func (s Spec) Start(ctx context.Context) (ret Interface) {
+	var cancel context.CancelFunc
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if s.Timeout <= 0 {
+		return s.New().Run(ctx, s.Args...)
+	}
+	ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+	if ret = s.New().Run(ctx, s.Args...); ret.Error() != nil {
+		cancel()
+		return
+	}
+	go func() { _, _ = ret.Wait(); cancel() }()
+
+	return
+}
+
+// RunWait Создание нового объекта процесса, запуск процесса и ожидание его завершения.
+// Процесс перезапускается в соответствии с политикой перезапуска, до прерывания через контекст.
+// Если процесс не удалось запустить, ошибка запуска возвращается без перезапуска.
+// Возвращается объект последнего запущенного процесса и ошибка его выполнения.
+func (s Spec) RunWait(ctx context.Context) (ret Interface, err error) {
+	var restarts int
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	for {
+		// Ошибка запуска не приводит к перезапуску, так как повторный запуск завершится той же ошибкой.
+		if ret = s.Start(ctx); ret.State() == StateFailed {
+			err = ret.Error()
+			return
+		}
+		_, err = ret.Wait()
+		if !s.Restart.need(ret, err) || ctx.Err() != nil {
+			return
+		}
+		if restarts++; s.Restart.MaxRestarts > 0 && restarts > s.Restart.MaxRestarts {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.Restart.Delay):
+		}
+	}
+}
+
+// Необходимость перезапуска процесса после завершения.
+func (rp RestartPolicy) need(run Interface, err error) bool {
+	var result *Result
+
+	switch rp.Mode {
+	case RestartAlways:
+		return true
+	case RestartOnFailure:
+		if result = run.Result(); err != nil || result == nil {
+			return true
+		}
+		return result.ExitCode != 0
+	default:
+		return false
+	}
+}
+
+// Копия настроек планирования.
+func (l Limits) clone() (ret Limits) {
+	ret.Nice = clonePtr(l.Nice)
+	ret.IOPriority = clonePtr(l.IOPriority)
+	ret.CPUAffinity = cloneSlice(l.CPUAffinity)
+	ret.SchedPolicy = clonePtr(l.SchedPolicy)
+	ret.OOMScoreAdj = clonePtr(l.OOMScoreAdj)
+
+	return
+}
+
+// Сравнение настроек планирования по значению.
+func (l Limits) equal(o Limits) bool {
+	return equalPtr(l.Nice, o.Nice) &&
+		equalPtr(l.IOPriority, o.IOPriority) &&
+		equalSlice(l.CPUAffinity, o.CPUAffinity) &&
+		equalPtr(l.SchedPolicy, o.SchedPolicy) &&
+		equalPtr(l.OOMScoreAdj, o.OOMScoreAdj)
+}
+
+// Применение настроек планирования к объекту процесса.
+func (l Limits) apply(run Interface) {
+	if l.Nice != nil {
+		run.Nice(*l.Nice)
+	}
+	if l.IOPriority != nil {
+		run.IOPriority(l.IOPriority.Class, l.IOPriority.Level)
+	}
+	if l.CPUAffinity != nil {
+		run.CPUAffinity(l.CPUAffinity...)
+	}
+	if l.SchedPolicy != nil {
+		run.SchedPolicy(*l.SchedPolicy)
+	}
+	if l.OOMScoreAdj != nil {
+		run.OOMScoreAdj(*l.OOMScoreAdj)
+	}
+}
+
+// Сравнение пользователей и групп по значению.
+func (c *Credential) equal(o *Credential) bool {
+	switch {
+	case c == nil || o == nil:
+		return c == o
+	default:
+		return c.UserID == o.UserID &&
+			c.GroupID == o.GroupID &&
+			c.NoSetGroups == o.NoSetGroups &&
+			equalSlice(c.Groups, o.Groups)
+	}
+}
+
+// Копия среза, значение nil сохраняется.
+func cloneSlice[T any](s []T) []T {
+	if s == nil {
+		return nil
+	}
+	return append(make([]T, 0, len(s)), s...)
+}
+
+// Копия значения указателя, значение nil сохраняется.
+func clonePtr[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
+
+// Сравнение срезов по значению, значение nil и пустой срез равны.
+func equalSlice[T comparable](a, b []T) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for n := range a {
+		if a[n] != b[n] {
+			return false
+		}
+	}
+	return true
+}
+
+// Сравнение значений указателей.
+func equalPtr[T comparable](a, b *T) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
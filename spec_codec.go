@@ -0,0 +1,820 @@
+package run
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SpecVersion Версия схемы сериализованного описания запуска.
+const SpecVersion = 1
+
+const specSizeMax = 1 << 30 // Максимальный размер буферов в описании запуска.
+
+// SpecFormat Формат сериализованного описания запуска.
+type SpecFormat int
+
+const (
+	// SpecJSON Формат JSON.
+	SpecJSON SpecFormat = iota
+
+	// SpecYAML Подмножество формата YAML: блочные объекты и списки, однострочные списки и объекты, строки,
+	// в том числе блочные строки "|" и ">", числа, логические значения и комментарии. Якоря, теги,
+	// многострочные строки без кавычек и в кавычках не поддерживаются.
+	SpecYAML
+)
+
+const (
+	errSpecType       = "ожидается %s, получено %s"
+	errSpecUnknown    = "неизвестное поле"
+	errSpecRequired   = "обязательное поле не указано"
+	errSpecVersion    = "неподдерживаемая версия схемы %s, поддерживается версия %d"
+	errSpecInteger    = "недопустимое целое число %q"
+	errSpecRange      = "значение %d вне допустимого диапазона от %d до %d"
+	errSpecEmpty      = "значение не может быть пустым"
+	errSpecEnv        = "переменная окружения %q не соответствует формату \"КЛЮЧ=Значение\""
+	errSpecDuration   = "недопустимая длительность %q"
+	errSpecNegative   = "длительность не может быть отрицательной"
+	errSpecEnum       = "недопустимое значение %q, допустимые значения: %s"
+	errSpecPerm       = "недопустимые права доступа %q, ожидается восьмеричное число от 0000 до 0777"
+	errSpecUser       = "пользователь %q не найден: %s"
+	errSpecGroup      = "группа %q не найдена: %s"
+	errSpecPrimary    = "группа не указана, а основную группу пользователя %d определить не удалось: %s"
+	errSpecNoUser     = "указана группа без пользователя"
+	errSpecStdErr     = "поток STDERR одновременно перенаправлен в файл и в поток STDOUT"
+	errSpecFlags      = "флаги открытия файла %#o не представимы в описании"
+	errSpecValue      = "значение %d не представимо в описании"
+	errSpecFormat     = "неизвестный формат файла %q, ожидается .json, .yaml или .yml"
+	errSpecRead       = "чтение файла %q прервано ошибкой: %w"
+	errSpecDocument   = "документ должен содержать описание запуска или список описаний"
+	errSpecFormatEnum = "неизвестный формат описания запуска %d"
+)
+
+var (
+	// Флаги открытия файлов перенаправления потоков.
+	specFlags = []struct {
+		name string
+		flag int
+	}{
+		{"rdwr", os.O_RDWR},
+		{"wronly", os.O_WRONLY},
+		{"append", os.O_APPEND},
+		{"create", os.O_CREATE},
+		{"excl", os.O_EXCL},
+		{"sync", os.O_SYNC},
+		{"trunc", os.O_TRUNC},
+	}
+
+	// Классы приоритета ввода-вывода.
+	specIOClasses = []string{
+		IOPriorityNone:       "none",
+		IOPriorityRealTime:   "realtime",
+		IOPriorityBestEffort: "best-effort",
+		IOPriorityIdle:       "idle",
+	}
+
+	// Политики планирования.
+	specSchedPolicies = []SchedPolicy{SchedOther, SchedBatch, SchedIdle}
+	specSchedNames    = []string{"other", "batch", "idle"}
+
+	// Условия перезапуска.
+	specRestartModes = []string{string(RestartNever), string(RestartOnFailure), string(RestartAlways)}
+)
+
+// ParseSpecs Разбор документа, содержащего описание запуска или список описаний.
+// Каждое описание содержит поле "version" с версией схемы. Ошибки проверки возвращаются как *SpecError
+// с путём к полю, содержащему ошибку.
+func ParseSpecs(data []byte, format SpecFormat) (ret []Spec, err error) {
+	var (
+		root *docNode
+		dec  = new(specDecoder)
+	)
+
+	switch format {
+	case SpecJSON:
+		root, err = docParseJSON(data)
+	case SpecYAML:
+		root, err = docParseYAML(data)
+	default:
+		err = fmt.Errorf(errSpecFormatEnum, format)
+	}
+	if err != nil {
+		return
+	}
+	switch root.kind {
+	case docMap:
+		ret = []Spec{dec.spec(root, "")}
+	case docList:
+		ret = make([]Spec, 0, len(root.items))
+		for n := range root.items {
+			ret = append(ret, dec.spec(root.items[n], docPathIndex("", n)))
+		}
+	default:
+		dec.fail(root, "", errors.New(errSpecDocument))
+	}
+	if err = dec.err; err != nil {
+		ret = nil
+	}
+
+	return
+}
+
+// LoadSpecs Загрузка описаний запуска из файла, формат определяется расширением файла: .json, .yaml или .yml.
+// Загруженные описания готовы к запуску через Start() и RunWait() или к созданию объекта процесса через New().
+func LoadSpecs(path string) (ret []Spec, err error) {
+	var (
+		data   []byte
+		format SpecFormat
+	)
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		format = SpecJSON
+	case ".yaml", ".yml":
+		format = SpecYAML
+	default:
+		err = fmt.Errorf(errSpecFormat, path)
+		return
+	}
+	if data, err = os.ReadFile(path); err != nil {
+		err = fmt.Errorf(errSpecRead, path, err)
+		return
+	}
+	if ret, err = ParseSpecs(data, format); err != nil {
+		err = fmt.Errorf(errSpecRead, path, err)
+	}
+
+	return
+}
+
+// Marshal Сериализация описания запуска в указанный формат с текущей версией схемы.
+// Пользователь и группы записываются идентификаторами.
+func (s Spec) Marshal(format SpecFormat) (ret []byte, err error) {
+	var (
+		root     *docNode
+		buf, out = new(bytes.Buffer), new(bytes.Buffer)
+	)
+
+	if root, err = s.document(); err != nil {
+		return
+	}
+	switch format {
+	case SpecJSON:
+		root.writeJSON(buf)
+		if err = json.Indent(out, buf.Bytes(), "", "  "); err != nil {
+			return
+		}
+		out.WriteByte('\n')
+		ret = out.Bytes()
+	case SpecYAML:
+		root.writeYAML(buf)
+		ret = buf.Bytes()
+	default:
+		err = fmt.Errorf(errSpecFormatEnum, format)
+	}
+
+	return
+}
+
+// MarshalJSON Реализация интерфейса json.Marshaler.
+func (s Spec) MarshalJSON() (ret []byte, err error) {
+	var (
+		root *docNode
+		buf  = new(bytes.Buffer)
+	)
+
+	if root, err = s.document(); err != nil {
+		return
+	}
+	root.writeJSON(buf)
+	ret = buf.Bytes()
+
+	return
+}
+
+// UnmarshalJSON Реализация интерфейса json.Unmarshaler, документ должен содержать одно описание запуска.
+func (s *Spec) UnmarshalJSON(data []byte) (err error) {
+	var (
+		root *docNode
+		dec  = new(specDecoder)
+		spec Spec
+	)
+
+	if root, err = docParseJSON(data); err != nil {
+		return
+	}
+	if spec = dec.spec(root, ""); dec.err != nil {
+		return dec.err
+	}
+	*s = spec
+
+	return
+}
+
+// Чтение описания запуска из дерева документа. Сохраняется первая возникшая ошибка, после ошибки
+// функции чтения возвращают нулевые значения.
+type specDecoder struct {
+	err error // Первая ошибка.
+}
+
+// Поля объекта документа с учётом прочитанных полей.
+type specObject struct {
+	node *docNode // Объект.
+	path string   // Путь к объекту.
+	seen []bool   // Прочитанные поля.
+}
+
+// Сохранение ошибки.
+func (dec *specDecoder) fail(n *docNode, path string, err error) {
+	if dec.err == nil {
+		dec.err = &SpecError{Path: path, Line: n.line, Err: err}
+	}
+}
+
+// Проверка типа узла. Значение null равно отсутствующему полю.
+func (dec *specDecoder) is(n *docNode, path string, kind int) bool {
+	if dec.err != nil || n == nil || n.kind == docNull {
+		return false
+	}
+	if n.kind != kind {
+		dec.fail(n, path, fmt.Errorf(errSpecType, docKindName(kind), docKindName(n.kind)))
+		return false
+	}
+	return true
+}
+
+// Объект.
+func (dec *specDecoder) object(n *docNode, path string) *specObject {
+	if !dec.is(n, path, docMap) {
+		return nil
+	}
+	return &specObject{node: n, path: path, seen: make([]bool, len(n.keys))}
+}
+
+// Значение поля объекта и путь к нему, nil - поле отсутствует.
+func (obj *specObject) field(key string) (*docNode, string) {
+	for i := range obj.node.keys {
+		if obj.node.keys[i] == key {
+			obj.seen[i] = true
+			return obj.node.items[i], docPathKey(obj.path, key)
+		}
+	}
+	return nil, docPathKey(obj.path, key)
+}
+
+// Проверка отсутствия неизвестных полей объекта.
+func (dec *specDecoder) done(obj *specObject) {
+	for i := range obj.seen {
+		if !obj.seen[i] {
+			dec.fail(obj.node.items[i], docPathKey(obj.path, obj.node.keys[i]), errors.New(errSpecUnknown))
+		}
+	}
+}
+
+// Строка.
+func (dec *specDecoder) str(n *docNode, path string) string {
+	if !dec.is(n, path, docString) {
+		return ""
+	}
+	return n.value
+}
+
+// Логическое значение.
+func (dec *specDecoder) boolean(n *docNode, path string) bool {
+	return dec.is(n, path, docBool) && n.value == "true"
+}
+
+// Целое число в диапазоне.
+func (dec *specDecoder) integer(n *docNode, path string, lo, hi int64) (ret int64) {
+	var err error
+
+	if !dec.is(n, path, docNumber) {
+		return
+	}
+	if ret, err = strconv.ParseInt(n.value, 10, 64); err != nil {
+		dec.fail(n, path, fmt.Errorf(errSpecInteger, n.value))
+		return 0
+	}
+	if ret < lo || ret > hi {
+		dec.fail(n, path, fmt.Errorf(errSpecRange, ret, lo, hi))
+		return 0
+	}
+
+	return
+}
+
+// Необязательное целое число в диапазоне.
+func (dec *specDecoder) intPtr(n *docNode, path string, lo, hi int64) *int {
+	if !dec.is(n, path, docNumber) {
+		return nil
+	}
+	v := int(dec.integer(n, path, lo, hi))
+	return &v
+}
+
+// Список строк. Присутствующий пустой список возвращается как пустой срез, а не nil.
+func (dec *specDecoder) strings(n *docNode, path string) (ret []string) {
+	if !dec.is(n, path, docList) {
+		return
+	}
+	ret = make([]string, 0, len(n.items))
+	for i := range n.items {
+		ret = append(ret, dec.str(n.items[i], docPathIndex(path, i)))
+	}
+
+	return
+}
+
+// Длительность в записи time.ParseDuration, например "1m30s".
+func (dec *specDecoder) duration(n *docNode, path string) (ret time.Duration) {
+	var (
+		text string
+		err  error
+	)
+
+	if text = dec.str(n, path); text == "" {
+		return
+	}
+	if ret, err = time.ParseDuration(text); err != nil {
+		dec.fail(n, path, fmt.Errorf(errSpecDuration, text))
+		return 0
+	}
+	if ret < 0 {
+		dec.fail(n, path, errors.New(errSpecNegative))
+		return 0
+	}
+
+	return
+}
+
+// Значение из списка допустимых значений, возвращается индекс значения.
+func (dec *specDecoder) enum(n *docNode, path string, values []string) int {
+	var text = dec.str(n, path)
+
+	for i := range values {
+		if values[i] != "" && values[i] == text {
+			return i
+		}
+	}
+	dec.fail(n, path, fmt.Errorf(errSpecEnum, text, strings.Join(values, ", ")))
+
+	return -1
+}
+
+// Описание запуска.
+func (dec *specDecoder) spec(n *docNode, path string) (ret Spec) {
+	var (
+		obj  *specObject
+		v    *docNode
+		p    string
+		args []string
+	)
+
+	// Значение null не является описанием запуска.
+	if obj = dec.object(n, path); obj == nil {
+		dec.fail(n, path, fmt.Errorf(errSpecType, docKindName(docMap), docKindName(n.kind)))
+		return
+	}
+	if v, p = obj.field("version"); v == nil || v.kind == docNull {
+		dec.fail(n, p, errors.New(errSpecRequired))
+	} else if dec.is(v, p, docNumber) && v.value != strconv.Itoa(SpecVersion) {
+		dec.fail(v, p, fmt.Errorf(errSpecVersion, v.value, SpecVersion))
+	}
+	if v, p = obj.field("args"); v == nil || v.kind == docNull {
+		dec.fail(n, p, errors.New(errSpecRequired))
+	} else if args = dec.strings(v, p); dec.err == nil && len(args) == 0 {
+		dec.fail(v, p, errors.New(errSpecEmpty))
+	} else if dec.err == nil && args[0] == "" {
+		dec.fail(v.items[0], docPathIndex(p, 0), errors.New(errSpecEmpty))
+	}
+	ret.Args = args
+	v, p = obj.field("env")
+	if ret.Env = dec.strings(v, p); dec.err == nil {
+		for i := range ret.Env {
+			if key, _, ok := strings.Cut(ret.Env[i], "="); !ok || key == "" {
+				dec.fail(v.items[i], docPathIndex(p, i), fmt.Errorf(errSpecEnv, ret.Env[i]))
+			}
+		}
+	}
+	ret.Dir = dec.str(obj.field("dir"))
+	ret.Chroot = dec.str(obj.field("chroot"))
+	ret.Credential = dec.credential(obj)
+	ret.ProcessGroup = dec.boolean(obj.field("process_group"))
+	ret.Cgroup = dec.str(obj.field("cgroup"))
+	ret.Limits = dec.limits(obj.field("limits"))
+	ret.Timeout = dec.duration(obj.field("timeout"))
+	ret.StdIn = dec.str(obj.field("stdin"))
+	ret.StdOut = dec.redirect(obj.field("stdout"))
+	ret.StdErr = dec.redirect(obj.field("stderr"))
+	v, p = obj.field("stderr_to_stdout")
+	if ret.StdErrToStdOut = dec.boolean(v, p); ret.StdErrToStdOut && ret.StdErr != nil {
+		dec.fail(v, p, errors.New(errSpecStdErr))
+	}
+	v, p = obj.field("buffer_size")
+	ret.BufferSize = int(dec.integer(v, p, 0, specSizeMax))
+	v, p = obj.field("channel_size")
+	ret.ChannelSize = int(dec.integer(v, p, 0, specSizeMax))
+	ret.Restart = dec.restart(obj.field("restart"))
+	dec.done(obj)
+
+	return
+}
+
+// Пользователь и группы. Пользователь и группы указываются идентификатором или именем,
+// если группа не указана, используется основная группа пользователя.
+func (dec *specDecoder) credential(obj *specObject) (ret *Credential) {
+	var (
+		uNode, gNode, lNode, nNode *docNode
+		uPath, gPath, lPath, nPath string
+		primary                    string
+		err                        error
+	)
+
+	uNode, uPath = obj.field("user")
+	gNode, gPath = obj.field("group")
+	lNode, lPath = obj.field("groups")
+	nNode, nPath = obj.field("no_set_groups")
+	if uNode == nil || uNode.kind == docNull {
+		for _, item := range []struct {
+			node *docNode
+			path string
+		}{{gNode, gPath}, {lNode, lPath}, {nNode, nPath}} {
+			if item.node != nil && item.node.kind != docNull {
+				dec.fail(item.node, item.path, errors.New(errSpecNoUser))
+			}
+		}
+		return
+	}
+	ret = new(Credential)
+	ret.UserID, primary = dec.userID(uNode, uPath)
+	if gNode != nil && gNode.kind != docNull {
+		ret.GroupID = dec.groupID(gNode, gPath)
+	} else if dec.err == nil {
+		if primary == "" {
+			var u *user.User
+			if u, err = user.LookupId(strconv.FormatUint(uint64(ret.UserID), 10)); err != nil {
+				dec.fail(uNode, gPath, fmt.Errorf(errSpecPrimary, ret.UserID, err))
+				return
+			}
+			primary = u.Gid
+		}
+		gid, _ := strconv.ParseUint(primary, 10, 32)
+		ret.GroupID = uint32(gid)
+	}
+	if dec.is(lNode, lPath, docList) {
+		ret.Groups = make([]uint32, 0, len(lNode.items))
+		for i := range lNode.items {
+			ret.Groups = append(ret.Groups, dec.groupID(lNode.items[i], docPathIndex(lPath, i)))
+		}
+	}
+	ret.NoSetGroups = dec.boolean(nNode, nPath)
+
+	return
+}
+
+// Идентификатор, указанный числом или строкой из цифр.
+func (dec *specDecoder) id(n *docNode, path string) (ret uint32, name string) {
+	switch {
+	case dec.err != nil:
+	case n.kind == docNumber:
+		ret = uint32(dec.integer(n, path, 0, 1<<32-1))
+	case n.kind == docString && n.value == "":
+		dec.fail(n, path, errors.New(errSpecEmpty))
+	case n.kind == docString:
+		if v, err := strconv.ParseUint(n.value, 10, 32); err == nil {
+			ret = uint32(v)
+		} else {
+			name = n.value
+		}
+	default:
+		dec.fail(n, path, fmt.Errorf(errSpecType, docKindName(docString), docKindName(n.kind)))
+	}
+
+	return
+}
+
+// Идентификатор пользователя и основной группы пользователя, если пользователь указан именем.
+func (dec *specDecoder) userID(n *docNode, path string) (ret uint32, primary string) {
+	var (
+		name string
+		u    *user.User
+		err  error
+	)
+
+	if ret, name = dec.id(n, path); name == "" {
+		return
+	}
+	if u, err = user.Lookup(name); err != nil {
+		dec.fail(n, path, fmt.Errorf(errSpecUser, name, err))
+		return
+	}
+	uid, _ := strconv.ParseUint(u.Uid, 10, 32)
+	ret, primary = uint32(uid), u.Gid
+
+	return
+}
+
+// Идентификатор группы.
+func (dec *specDecoder) groupID(n *docNode, path string) (ret uint32) {
+	var (
+		name string
+		g    *user.Group
+		err  error
+	)
+
+	if ret, name = dec.id(n, path); name == "" {
+		return
+	}
+	if g, err = user.LookupGroup(name); err != nil {
+		dec.fail(n, path, fmt.Errorf(errSpecGroup, name, err))
+		return
+	}
+	gid, _ := strconv.ParseUint(g.Gid, 10, 32)
+	ret = uint32(gid)
+
+	return
+}
+
+// Настройки планирования и приоритетов.
+func (dec *specDecoder) limits(n *docNode, path string) (ret Limits) {
+	var (
+		obj, prio *specObject
+		v         *docNode
+		p         string
+	)
+
+	if obj = dec.object(n, path); obj == nil {
+		return
+	}
+	v, p = obj.field("nice")
+	ret.Nice = dec.intPtr(v, p, -20, 19)
+	v, p = obj.field("io_priority")
+	if prio = dec.object(v, p); prio != nil {
+		ret.IOPriority = new(IOPriority)
+		if cv, cp := prio.field("class"); cv == nil || cv.kind == docNull {
+			dec.fail(v, cp, errors.New(errSpecRequired))
+		} else {
+			ret.IOPriority.Class = IOPriorityClass(dec.enum(cv, cp, specIOClasses))
+		}
+		v, p = prio.field("level")
+		ret.IOPriority.Level = int(dec.integer(v, p, 0, 7))
+		dec.done(prio)
+	}
+	if v, p = obj.field("cpu_affinity"); dec.is(v, p, docList) {
+		ret.CPUAffinity = make([]int, 0, len(v.items))
+		for i := range v.items {
+			ret.CPUAffinity = append(ret.CPUAffinity, int(dec.integer(v.items[i], docPathIndex(p, i), 0, 1<<16)))
+		}
+	}
+	if v, p = obj.field("sched_policy"); dec.is(v, p, docString) {
+		if i := dec.enum(v, p, specSchedNames); i >= 0 {
+			policy := specSchedPolicies[i]
+			ret.SchedPolicy = &policy
+		}
+	}
+	v, p = obj.field("oom_score_adj")
+	ret.OOMScoreAdj = dec.intPtr(v, p, -1000, 1000)
+	dec.done(obj)
+
+	return
+}
+
+// Перенаправление потока в файл.
+func (dec *specDecoder) redirect(n *docNode, path string) (ret *Redirect) {
+	var (
+		obj  *specObject
+		v    *docNode
+		p    string
+		perm uint64
+		err  error
+	)
+
+	if obj = dec.object(n, path); obj == nil {
+		return
+	}
+	ret = new(Redirect)
+	if v, p = obj.field("path"); v == nil || v.kind == docNull {
+		dec.fail(n, p, errors.New(errSpecRequired))
+	} else if ret.Path = dec.str(v, p); dec.err == nil && ret.Path == "" {
+		dec.fail(v, p, errors.New(errSpecEmpty))
+	}
+	if v, p = obj.field("flags"); dec.is(v, p, docList) {
+		names := make([]string, 0, len(specFlags))
+		for _, item := range specFlags {
+			names = append(names, item.name)
+		}
+		for i := range v.items {
+			if k := dec.enum(v.items[i], docPathIndex(p, i), names); k >= 0 {
+				ret.Flags |= specFlags[k].flag
+			}
+		}
+	}
+	if v, p = obj.field("perm"); dec.is(v, p, docString) {
+		if perm, err = strconv.ParseUint(v.value, 8, 32); err != nil || perm > uint64(os.ModePerm) {
+			dec.fail(v, p, fmt.Errorf(errSpecPerm, v.value))
+		}
+		ret.Perm = os.FileMode(perm)
+	}
+	dec.done(obj)
+
+	return
+}
+
+// Политика перезапуска.
+func (dec *specDecoder) restart(n *docNode, path string) (ret RestartPolicy) {
+	var (
+		obj *specObject
+		v   *docNode
+		p   string
+	)
+
+	if obj = dec.object(n, path); obj == nil {
+		return
+	}
+	if v, p = obj.field("mode"); dec.is(v, p, docString) {
+		if i := dec.enum(v, p, specRestartModes); i >= 0 {
+			ret.Mode = RestartMode(specRestartModes[i])
+		}
+	}
+	v, p = obj.field("max_restarts")
+	ret.MaxRestarts = int(dec.integer(v, p, 0, 1<<31-1))
+	ret.Delay = dec.duration(obj.field("delay"))
+	dec.done(obj)
+
+	return
+}
+
+// Дерево документа описания запуска. Нулевые значения не записываются.
+func (s Spec) document() (ret *docNode, err error) {
+	var node *docNode
+
+	ret = docObjectNew().set("version", docInt(SpecVersion))
+	ret.set("args", docStrings(s.Args))
+	if s.Env != nil {
+		ret.set("env", docStrings(s.Env))
+	}
+	docSetString(ret, "dir", s.Dir)
+	docSetString(ret, "chroot", s.Chroot)
+	if s.Credential != nil {
+		ret.set("user", docInt(int64(s.Credential.UserID)))
+		ret.set("group", docInt(int64(s.Credential.GroupID)))
+		if s.Credential.Groups != nil {
+			node = docListNew()
+			for _, gid := range s.Credential.Groups {
+				node.items = append(node.items, docInt(int64(gid)))
+			}
+			ret.set("groups", node)
+		}
+		if s.Credential.NoSetGroups {
+			ret.set("no_set_groups", docBoolean(true))
+		}
+	}
+	if s.ProcessGroup {
+		ret.set("process_group", docBoolean(true))
+	}
+	docSetString(ret, "cgroup", s.Cgroup)
+	if node, err = s.Limits.document(); err != nil {
+		return
+	}
+	if len(node.keys) > 0 {
+		ret.set("limits", node)
+	}
+	if s.Timeout > 0 {
+		ret.set("timeout", docStr(s.Timeout.String()))
+	}
+	docSetString(ret, "stdin", s.StdIn)
+	for _, item := range []struct {
+		name string
+		rdr  *Redirect
+	}{{"stdout", s.StdOut}, {"stderr", s.StdErr}} {
+		if item.rdr == nil {
+			continue
+		}
+		if node, err = item.rdr.document(item.name); err != nil {
+			return
+		}
+		ret.set(item.name, node)
+	}
+	if s.StdErrToStdOut {
+		ret.set("stderr_to_stdout", docBoolean(true))
+	}
+	if s.BufferSize > 0 {
+		ret.set("buffer_size", docInt(int64(s.BufferSize)))
+	}
+	if s.ChannelSize > 0 {
+		ret.set("channel_size", docInt(int64(s.ChannelSize)))
+	}
+	if s.Restart != (RestartPolicy{}) {
+		node = docObjectNew()
+		docSetString(node, "mode", string(s.Restart.Mode))
+		if s.Restart.MaxRestarts > 0 {
+			node.set("max_restarts", docInt(int64(s.Restart.MaxRestarts)))
+		}
+		if s.Restart.Delay > 0 {
+			node.set("delay", docStr(s.Restart.Delay.String()))
+		}
+		ret.set("restart", node)
+	}
+
+	return
+}
+
+// Дерево документа настроек планирования и приоритетов.
+func (l Limits) document() (ret *docNode, err error) {
+	var node *docNode
+
+	ret = docObjectNew()
+	if l.Nice != nil {
+		ret.set("nice", docInt(int64(*l.Nice)))
+	}
+	if l.IOPriority != nil {
+		if l.IOPriority.Class < 0 || int(l.IOPriority.Class) >= len(specIOClasses) {
+			err = &SpecError{Path: "limits.io_priority.class", Err: fmt.Errorf(errSpecValue, l.IOPriority.Class)}
+			return
+		}
+		ret.set("io_priority", docObjectNew().
+			set("class", docStr(specIOClasses[l.IOPriority.Class])).
+			set("level", docInt(int64(l.IOPriority.Level))))
+	}
+	if l.CPUAffinity != nil {
+		node = docListNew()
+		for _, cpu := range l.CPUAffinity {
+			node.items = append(node.items, docInt(int64(cpu)))
+		}
+		ret.set("cpu_affinity", node)
+	}
+	if l.SchedPolicy != nil {
+		var name string
+		for i := range specSchedPolicies {
+			if specSchedPolicies[i] == *l.SchedPolicy {
+				name = specSchedNames[i]
+			}
+		}
+		if name == "" {
+			err = &SpecError{Path: "limits.sched_policy", Err: fmt.Errorf(errSpecValue, *l.SchedPolicy)}
+			return
+		}
+		ret.set("sched_policy", docStr(name))
+	}
+	if l.OOMScoreAdj != nil {
+		ret.set("oom_score_adj", docInt(int64(*l.OOMScoreAdj)))
+	}
+
+	return
+}
+
+// Дерево документа перенаправления потока в файл.
+func (rdr *Redirect) document(path string) (ret *docNode, err error) {
+	var (
+		flags = rdr.Flags
+		node  *docNode
+	)
+
+	ret = docObjectNew().set("path", docStr(rdr.Path))
+	if flags != 0 {
+		node = docListNew()
+		for _, item := range specFlags {
+			if flags&item.flag == item.flag && item.flag != 0 {
+				node.items, flags = append(node.items, docStr(item.name)), flags&^item.flag
+			}
+		}
+		if flags != 0 {
+			err = &SpecError{Path: docPathKey(path, "flags"), Err: fmt.Errorf(errSpecFlags, flags)}
+			return
+		}
+		ret.set("flags", node)
+	}
+	if rdr.Perm&^os.ModePerm != 0 {
+		err = &SpecError{Path: docPathKey(path, "perm"), Err: fmt.Errorf(errSpecPerm, rdr.Perm.String())}
+		return
+	}
+	if rdr.Perm != 0 {
+		ret.set("perm", docStr(fmt.Sprintf("%04o", uint32(rdr.Perm))))
+	}
+
+	return
+}
+
+// Список строк.
+func docStrings(values []string) (ret *docNode) {
+	ret = docListNew()
+	for _, value := range values {
+		ret.items = append(ret.items, docStr(value))
+	}
+
+	return
+}
+
+// Добавление не пустого строкового поля объекта.
+func docSetString(n *docNode, key string, value string) {
+	if value != "" {
+		n.set(key, docStr(value))
+	}
+}
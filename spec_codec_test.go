@@ -0,0 +1,190 @@
+package run
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+// Описание запуска, в котором установлены все поля.
+func testSpecFull() Spec {
+	var (
+		nice, oom = 5, -100
+		policy    = SchedBatch
+	)
+
+	return Spec{
+		Args:         []string{"sh", "-c", `echo "$A" # не комментарий`, "", "0640", "true", "- x", "key: v", "null"},
+		Env:          []string{"A=b c", "EMPTY=", `Q='"x`},
+		Dir:          "/tmp/work dir",
+		Chroot:       "/srv/root",
+		Credential:   &Credential{UserID: 1000, GroupID: 1001, NoSetGroups: true, Groups: []uint32{10, 20}},
+		ProcessGroup: true,
+		Cgroup:       "/sys/fs/cgroup/job",
+		Limits: Limits{
+			Nice:        &nice,
+			IOPriority:  &IOPriority{Class: IOPriorityBestEffort, Level: 4},
+			CPUAffinity: []int{0, 2},
+			SchedPolicy: &policy,
+			OOMScoreAdj: &oom,
+		},
+		StdIn:       "/dev/null",
+		StdOut:      &Redirect{Path: "/tmp/out.log", Flags: os.O_WRONLY | os.O_CREATE | os.O_APPEND, Perm: 0640},
+		StdErr:      &Redirect{Path: "/tmp/err.log", Flags: os.O_WRONLY | os.O_CREATE | os.O_TRUNC, Perm: 0600},
+		BufferSize:  4096,
+		ChannelSize: 8,
+		Timeout:     90 * time.Second,
+		Restart:     RestartPolicy{Mode: RestartOnFailure, MaxRestarts: 3, Delay: 1500 * time.Millisecond},
+	}
+}
+
+func TestSpecRoundTrip(t *testing.T) {
+	var tests = []struct {
+		name string
+		spec Spec
+	}{
+		{"full", testSpecFull()},
+		{"minimal", NewSpec("true")},
+		{"empty env", Spec{Args: []string{"env"}, Env: []string{}}},
+		{"stderr to stdout", Spec{Args: []string{"ls"}, StdErrToStdOut: true, Restart: RestartPolicy{Mode: RestartAlways}}},
+	}
+
+	for _, test := range tests {
+		for _, format := range []SpecFormat{SpecJSON, SpecYAML} {
+			data, err := test.spec.Marshal(format)
+			if err != nil {
+				t.Fatalf("%s/%d: ошибка сериализации: %v", test.name, format, err)
+			}
+			specs, err := ParseSpecs(data, format)
+			if err != nil {
+				t.Fatalf("%s/%d: ошибка разбора: %v\n%s", test.name, format, err, data)
+			}
+			if len(specs) != 1 || !specs[0].Equal(test.spec) {
+				t.Errorf("%s/%d: описание изменилось после сериализации:\n%s\n%#v", test.name, format, data, specs)
+			}
+		}
+	}
+}
+
+func TestSpecJSONInterface(t *testing.T) {
+	var (
+		spec = testSpecFull()
+		got  []Spec
+	)
+
+	data, err := json.Marshal([]Spec{spec, spec})
+	if err != nil {
+		t.Fatalf("ошибка json.Marshal: %v", err)
+	}
+	if err = json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("ошибка json.Unmarshal: %v", err)
+	}
+	if len(got) != 2 || !got[0].Equal(spec) || !got[1].Equal(spec) {
+		t.Errorf("описание изменилось после json.Marshal и json.Unmarshal: %s", data)
+	}
+}
+
+func TestSpecVersion(t *testing.T) {
+	var tests = []struct {
+		name   string
+		data   string
+		format SpecFormat
+		path   string
+	}{
+		{"missing", "args: [a]\n", SpecYAML, "version"},
+		{"zero", "version: 0\nargs: [a]\n", SpecYAML, "version"},
+		{"future", "version: 2\nargs: [a]\n", SpecYAML, "version"},
+		{"fraction", "version: 1.5\nargs: [a]\n", SpecYAML, "version"},
+		{"string", "version: \"1\"\nargs: [a]\n", SpecYAML, "version"},
+		{"future json", `{"version":2,"args":["a"]}`, SpecJSON, "version"},
+		{"list item", `[{"version":1,"args":["a"]},{"version":3,"args":["b"]}]`, SpecJSON, "[1].version"},
+	}
+
+	for _, test := range tests {
+		_, err := ParseSpecs([]byte(test.data), test.format)
+		var se *SpecError
+		if !errors.As(err, &se) {
+			t.Errorf("%s: ожидается *SpecError, получено %v", test.name, err)
+			continue
+		}
+		if se.Path != test.path {
+			t.Errorf("%s: путь к полю %q, ожидается %q", test.name, se.Path, test.path)
+		}
+	}
+	if _, err := ParseSpecs([]byte("version: 1\nargs: [a]\n"), SpecYAML); err != nil {
+		t.Errorf("поддерживаемая версия отклонена: %v", err)
+	}
+}
+
+func TestSpecMalformed(t *testing.T) {
+	var tests = []struct {
+		name   string
+		data   string
+		format SpecFormat
+		line   int
+	}{
+		{"indent list", "version: 1\nargs:\n  - a\n   - b\n", SpecYAML, 4},
+		{"indent key", "version: 1\n  args: [a]\n", SpecYAML, 2},
+		{"single quote", "version: 1\nargs: ['a]\n", SpecYAML, 2},
+		{"double quote", "version: 1\nargs: [\"a\n", SpecYAML, 2},
+		{"unknown field", "version: 1\nargs: [a]\nbogus: 1\n", SpecYAML, 3},
+		{"duplicate key", "version: 1\nargs: [a]\nargs: [b]\n", SpecYAML, 3},
+		{"wrong type", "version: 1\nlimits:\n  nice: x\nargs: [a]\n", SpecYAML, 3},
+		{"bad perm", "version: 1\nargs: [a]\nstdout:\n  path: /tmp/x\n  perm: 0999\n", SpecYAML, 5},
+		{"bad duration", "version: 1\nargs: [a]\ntimeout: soon\n", SpecYAML, 3},
+		{"scalar document", "just text\n", SpecYAML, 0},
+		{"trailing data", `{"version":1,"args":["a"]} x`, SpecJSON, 0},
+		{"duplicate json", `{"version":1,"args":["a"],"args":["b"]}`, SpecJSON, 0},
+		{"truncated json", `{"version":1,"args":["a"`, SpecJSON, 0},
+		{"missing args", `[{"version":1,"args":["a"]},{"version":1}]`, SpecJSON, 0},
+	}
+
+	for _, test := range tests {
+		specs, err := ParseSpecs([]byte(test.data), test.format)
+		if err == nil {
+			t.Errorf("%s: ошибка не обнаружена, получено %#v", test.name, specs)
+			continue
+		}
+		var se *SpecError
+		if test.line > 0 && (!errors.As(err, &se) || se.Line != test.line) {
+			t.Errorf("%s: ожидается ошибка в строке %d, получено %v", test.name, test.line, err)
+		}
+	}
+}
+
+func TestSpecYAMLBlock(t *testing.T) {
+	const head = "version: 1\nargs:\n  - sh\n"
+	var tests = []struct {
+		name string
+		data string
+		arg  string
+		dir  string
+	}{
+		{"literal", head + "  - |\n    echo a\n      # не комментарий\n\n    echo b\n\n", "echo a\n  # не комментарий\n\necho b\n", ""},
+		{"literal strip", head + "  - |-\n    a\n\n", "a", ""},
+		{"literal keep", head + "  - |+\n    a\n\n\n", "a\n\n\n", ""},
+		{"literal indent", head + "  - |2\n      a\n     b\n", "  a\n b\n", ""},
+		{"literal indent and chomp", head + "  - |-1\n    a\n", " a", ""},
+		{"literal leading blank", head + "  - |\n\n    a\n", "\na\n", ""},
+		{"folded", head + "  - >\n    a\n    b\n\n    c\n      d\n    e\n", "a b\nc\n  d\ne\n", ""},
+		{"folded blank lines", head + "  - >-\n    a\n\n\n    b\n", "a\n\nb", ""},
+		{"comment after header", head + "  - | # комментарий\n    a\n", "a\n", ""},
+		{"empty", head + "  - |\ndir: /w\n", "", "/w"},
+		{"field", "version: 1\ndir: >-\n  /a\n  b\nargs:\n  - sh\n  - x\n", "x", "/a b"},
+		{"next item", head + "  - |-\n    a\n  - b\ndir: /w\n", "a", "/w"},
+		{"list of specs", "- version: 1\n  dir: |-\n    /w\n  args: [sh, x]\n", "x", "/w"},
+	}
+
+	for _, test := range tests {
+		specs, err := ParseSpecs([]byte(test.data), SpecYAML)
+		if err != nil {
+			t.Errorf("%s: ошибка разбора: %v", test.name, err)
+			continue
+		}
+		if len(specs) != 1 || len(specs[0].Args) < 2 || specs[0].Args[1] != test.arg || specs[0].Dir != test.dir {
+			t.Errorf("%s: получено %+v", test.name, specs)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+//go:build linux
+
+package run
+
+import (
+	"io"
+	"os"
+	"syscall"
+)
+
+const (
+	spliceMove     = 0x1 // SPLICE_F_MOVE
+	spliceNonblock = 0x2 // SPLICE_F_NONBLOCK
+)
+
+// Файл может быть получателем данных системного вызова splice без ожидания готовности к записи.
+// Для труб и сокетов используется копирование через память процесса.
+func spliceTargetCheck(fh *os.File) bool {
+	var (
+		err error
+		fi  os.FileInfo
+	)
+
+	if fi, err = fh.Stat(); err != nil {
+		return false
+	}
+
+	return fi.Mode().IsRegular() || fi.Mode()&os.ModeCharDevice != 0
+}
+
+// Передача не более size байт из трубы src в файл dst системным вызовом splice, без копирования данных
+// через память процесса. Если передача между указанными файлами невозможна, возвращается errSpliceUnsupported.
+func spliceFrom(src *os.File, dst *os.File, size int) (n int, err error) {
+	var (
+		rc, wc syscall.RawConn
+		rerr   error
+		serr   error
+	)
+
+	if rc, err = src.SyscallConn(); err != nil {
+		return
+	}
+	if wc, err = dst.SyscallConn(); err != nil {
+		return
+	}
+	// Ошибка ожидания чтения, например закрытие трубы, хранится отдельно от ошибки Control().
+	err = wc.Control(func(wfd uintptr) {
+		rerr = rc.Read(func(rfd uintptr) bool {
+			var k, e = syscall.Splice(int(rfd), nil, int(wfd), nil, size, spliceMove|spliceNonblock)
+			n, serr = int(k), e
+			// Ожидание поступления данных в трубу.
+			return serr != syscall.EAGAIN
+		})
+	})
+	switch {
+	case err != nil:
+	case rerr != nil:
+		n, err = 0, rerr
+	case serr == syscall.EINVAL || serr == syscall.ENOSYS || serr == syscall.EBADF:
+		n, err = 0, errSpliceUnsupported
+	case serr != nil:
+		n, err = 0, serr
+	case n == 0:
+		err = io.EOF
+	}
+
+	return
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package run
+
+import "os"
+
+// Файл может быть получателем данных системного вызова splice.
+func spliceTargetCheck(_ *os.File) bool { return false }
+
+// Системный вызов splice доступен только на Linux.
+func spliceFrom(_ *os.File, _ *os.File, _ int) (int, error) { return 0, errSpliceUnsupported }
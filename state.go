@@ -0,0 +1,165 @@
+package run
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// State Состояние процесса.
+type State uint32
+
+const (
+	// StateConfigured Процесс не запускался, выполняется настройка запуска.
+	StateConfigured State = iota
+
+	// StateStarting Выполняется запуск процесса.
+	StateStarting
+
+	// StateRunning Процесс выполняется.
+	StateRunning
+
+	// StatePaused Выполнение процесса приостановлено.
+	StatePaused
+
+	// StateStopping Процессу отправлен запрос на завершение, процесс ещё выполняется.
+	StateStopping
+
+	// StateExited Процесс завершён.
+	StateExited
+
+	// StateFailed Процесс не удалось запустить.
+	StateFailed
+)
+
+// Допустимые переходы между состояниями. Переход в состояние StateConfigured, выполняемый функцией Reset(),
+// допустим из любого состояния.
+var stateTransitions = map[State][]State{
+	StateConfigured: {StateStarting, StateFailed},
+	StateStarting:   {StateRunning, StateFailed},
+	StateRunning:    {StatePaused, StateStopping, StateExited},
+	StatePaused:     {StateRunning, StateStopping, StateExited},
+	StateStopping:   {StateExited},
+	StateExited:     {},
+	StateFailed:     {},
+}
+
+// String Реализация интерфейса fmt.Stringer.
+func (s State) String() string {
+	switch s {
+	case StateConfigured:
+		return "configured"
+	case StateStarting:
+		return "starting"
+	case StateRunning:
+		return "running"
+	case StatePaused:
+		return "paused"
+	case StateStopping:
+		return "stopping"
+	case StateExited:
+		return "exited"
+	case StateFailed:
+		return "failed"
+	default:
+		return "state(" + strconv.FormatUint(uint64(s), 10) + ")"
+	}
+}
+
+// Alive Возвращает истину для состояний, в которых процесс существует.
+func (s State) Alive() bool { return s == StateRunning || s == StatePaused || s == StateStopping }
+
+// StateError Ошибка выполнения операции, недопустимой в текущем состоянии процесса.
+type StateError struct {
+	Op    string // Название операции.
+	State State  // Состояние процесса, в котором вызвана операция.
+}
+
+// Error Реализация интерфейса error.
+func (se *StateError) Error() string {
+	const errState = "операция %s недопустима в состоянии процесса %q"
+	return fmt.Sprintf(errState, se.Op, se.State)
+}
+
+// Проверка допустимости перехода между состояниями.
+func stateCanTransit(from State, to State) bool {
+	if to == StateConfigured {
+		return true
+	}
+	for _, s := range stateTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Переход в новое состояние. Если переход недопустим, возвращается ошибка StateError для операции op.
+func (run *impl) stateSet(op string, to State) (err error) {
+	const msgState = "state.change"
+	var from State
+
+	run.stateSync.Lock()
+	if from = run.state; !stateCanTransit(from, to) {
+		run.stateSync.Unlock()
+		err = &StateError{Op: op, State: from}
+		return
+	}
+	run.state = to
+	close(run.stateCh)
+	run.stateCh = make(chan struct{})
+	run.stateSync.Unlock()
+	run.log(LevelDebug, msgState, attr("op", op), attr("from", from), attr("to", to))
+
+	return
+}
+
+// Проверка того, что текущее состояние является одним из указанных состояний.
+// Если состояние не совпадает ни с одним из указанных, возвращается ошибка StateError для операции op.
+func (run *impl) stateIs(op string, states ...State) (err error) {
+	var current = run.State()
+
+	for _, s := range states {
+		if s == current {
+			return
+		}
+	}
+	err = &StateError{Op: op, State: current}
+
+	return
+}
+
+// State Состояние процесса.
+func (run *impl) State() (ret State) {
+	run.stateSync.Lock()
+	ret = run.state
+	run.stateSync.Unlock()
+
+	return
+}
+
+// WaitState Ожидание перехода процесса в указанное состояние.
+// Функция возвращает управление сразу, если процесс уже находится в указанном состоянии, либо после прерывания
+// через контекст, возвращая ошибку контекста.
+func (run *impl) WaitState(ctx context.Context, state State) (err error) {
+	var ch chan struct{}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	for {
+		run.stateSync.Lock()
+		if run.state == state {
+			run.stateSync.Unlock()
+			return
+		}
+		ch = run.stateCh
+		run.stateSync.Unlock()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ch:
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package run
+
+import (
+	"context"
+	"time"
+)
+
+const statsInterval = time.Second // Интервал сбора статистики по умолчанию.
+
+// Stats Статистика использования ресурсов запущенным процессом.
+type Stats struct {
+	Time        time.Time     // Время получения статистики.
+	Pid         int           // Идентификатор процесса.
+	State       string        // Состояние процесса, в формате /proc/<pid>/stat (R, S, D, Z, T и т.д.).
+	RSS         uint64        // Размер резидентной памяти в байтах.
+	VMPeak      uint64        // Пиковый размер виртуальной памяти в байтах.
+	CPUUser     time.Duration // Время выполнения в режиме пользователя.
+	CPUSystem   time.Duration // Время выполнения в режиме ядра.
+	Threads     int           // Количество потоков.
+	FDs         int           // Количество открытых файловых дескрипторов.
+	ReadBytes   uint64        // Количество байт, прочитанных с устройств хранения.
+	WriteBytes  uint64        // Количество байт, записанных на устройства хранения.
+	Descendants []*Stats      // Статистика потомков процесса, если она была запрошена.
+}
+
+// Stats Статистика использования ресурсов запущенным процессом, полученная из /proc/<pid>.
+// Если descendants равен истине, дополнительно собирается статистика всех потомков процесса.
+func (run *impl) Stats(descendants bool) (ret *Stats, err error) {
+	const opStats = "Stats"
+	var (
+		pid  int
+		pids []int
+		st   *Stats
+	)
+
+	if err = run.stateIs(opStats, StateRunning, StatePaused, StateStopping); err != nil {
+		return
+	}
+	if pid = run.Pid(); pid < 0 {
+		err = &StateError{Op: opStats, State: run.State()}
+		return
+	}
+	if ret, err = procStats(pid); err != nil || !descendants {
+		return
+	}
+	if pids, err = procDescendants(pid); err != nil {
+		return
+	}
+	for _, child := range pids {
+		// Потомок мог завершиться во время сбора статистики.
+		if st, err = procStats(child); err != nil {
+			err = nil
+			continue
+		}
+		ret.Descendants = append(ret.Descendants, st)
+	}
+
+	return
+}
+
+// StatsCh Канал с периодической статистикой использования ресурсов запущенным процессом.
+// Если процесс ещё не запущен, отправка статистики начинается после запуска процесса. Статистика отправляется
+// в канал с указанным интервалом, канал закрывается после завершения процесса, неудачного запуска или
+// прерывания через контекст. Если канал не успевает читаться, значения пропускаются. Если интервал не
+// больше нуля, используется интервал по умолчанию 1 секунда.
+func (run *impl) StatsCh(ctx context.Context, interval time.Duration, descendants bool) (ret <-chan *Stats) {
+	const msgStats = "stats.subscribe"
+	var ch chan *Stats
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if interval <= 0 {
+		interval = statsInterval
+	}
+	_, chanLen := run.sizeGet()
+	ch = make(chan *Stats, chanLen)
+	run.log(LevelDebug, msgStats, attr("interval", interval), attr("descendants", descendants))
+	go run.goStats(ctx, ch, interval, descendants)
+	ret = ch
+
+	return
+}
+
+// Функция выполняет задачу периодического сбора статистики процесса.
+func (run *impl) goStats(ctx context.Context, ch chan<- *Stats, interval time.Duration, descendants bool) {
+	var (
+		ticker *time.Ticker
+		st     *Stats
+		err    error
+	)
+
+	defer close(ch)
+	if !run.statsWaitStart(ctx) {
+		return
+	}
+	ticker = time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if st, err = run.Stats(descendants); err != nil {
+			return
+		}
+		select {
+		case ch <- st:
+		default:
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Ожидание запуска процесса, если процесс ещё не запущен. Возвращается истина, если процесс запущен, и ложь,
+// если процесс завершён, не удалось запустить или ожидание прервано через контекст.
+func (run *impl) statsWaitStart(ctx context.Context) bool {
+	var (
+		state State
+		ch    chan struct{}
+	)
+
+	for {
+		run.stateSync.Lock()
+		state, ch = run.state, run.stateCh
+		run.stateSync.Unlock()
+		switch {
+		case state.Alive():
+			return true
+		case state != StateConfigured && state != StateStarting:
+			return false
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ch:
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package run
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// Канал статистики, полученный до запуска процесса, передаёт статистику после запуска процесса.
+func TestStatsChBeforeRun(t *testing.T) {
+	var (
+		run   = New()
+		count int
+	)
+
+	ch := run.StatsCh(context.Background(), 50*time.Millisecond, false)
+	time.Sleep(50 * time.Millisecond)
+	if err := run.Run(context.Background(), "sleep", "0.5").Error(); err != nil {
+		t.Fatalf("запуск процесса прерван ошибкой: %v", err)
+	}
+	for st := range ch {
+		if st == nil {
+			t.Errorf("получена пустая статистика")
+		}
+		count++
+	}
+	if count == 0 {
+		t.Errorf("статистика запущенного процесса не получена")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := run.WaitState(ctx, StateExited); err != nil {
+		t.Fatalf("ожидание завершения процесса прервано ошибкой: %v", err)
+	}
+	// После завершения процесса канал закрывается без отправки статистики.
+	if _, ok := <-run.StatsCh(context.Background(), 0, false); ok {
+		t.Errorf("получена статистика завершённого процесса")
+	}
+}
+
+// Канал статистики закрывается после прерывания через контекст, если процесс не запущен.
+func TestStatsChCancel(t *testing.T) {
+	var ctx, cancel = context.WithCancel(context.Background())
+
+	ch := New().StatsCh(ctx, 0, false)
+	cancel()
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Errorf("получена статистика не запущенного процесса")
+		}
+	case <-time.After(time.Second):
+		t.Errorf("канал статистики не закрыт после прерывания через контекст")
+	}
+}
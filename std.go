@@ -1,45 +1,110 @@
 package run
 
+import (
+	"fmt"
+	"io"
+)
+
 // StdInCh Канал с данными для потока STDIN. Канал должен быть закрыт там же где открывался.
 // Функция читает канал и передаёт процессу данные, до тех пор пока канал открыт и процесс запущен.
-func (run *impl) StdInCh(ch <-chan []byte) Interface { run.externalInpCh = ch; return run }
+// Срезы передаются процессу без копирования и не должны изменяться после отправки в канал.
+func (run *impl) StdInCh(ch <-chan []byte) Interface {
+	run.fieldSync.Lock()
+	run.externalInpCh = ch
+	run.fieldSync.Unlock()
+
+	return run
+}
 
 // StdIn Данные, отправляемые процессу в поток STDIN после запуска процесса.
 func (run *impl) StdIn(buf []byte) Interface {
-	const errTpl = "запись среза байт в буфер STDIN прервана ошибкой: %s"
+	const errTpl = "stdin.buffer.failed"
+	var onNewData chan struct{}
 
-	if _, err := run.bufInp.Write(buf); err != nil {
-		run.debug(errTpl, err)
+	run.fieldSync.Lock()
+	_, err := run.bufInp.Write(buf)
+	onNewData = run.onNewData
+	run.fieldSync.Unlock()
+	if err != nil {
+		run.log(LevelError, errTpl, attr("stream", "stdin"), attr("bytes", len(buf)), attr("error", err))
+	}
+	// Сигнал не отправляется, если предыдущий сигнал ещё не обработан, данные будут переданы вместе.
+	select {
+	case onNewData <- struct{}{}:
+	default:
 	}
-	chanSendSignal(run.onNewData)
 
 	return run
 }
 
 // StdOutCh Канал с данными полученными из процесса через поток STDOUT. Канал будет закрыт после завершения
-// процесса. Канал не создаётся, если функция не вызывалась.
-func (run *impl) StdOutCh() (ret <-chan []byte) {
-	const msgStdOut = "открыт внешний канал STDOUT"
+// процесса. Каждый вызов функции создаёт нового подписчика, получающего все новые данные потока.
+func (run *impl) StdOutCh() <-chan []byte { return run.StdOutSubscribe(nil).Ch() }
 
-	run.debug(msgStdOut)
-	run.externalOutCh = make(chan []byte, run.chanLen)
+// StdOutSubscribe Подписка на данные потока STDOUT. Подписка возможна как до, так и после запуска процесса.
+// Если настройки не указаны, подписчик получает только новые данные, а передача данных ожидает освобождения
+// буфера канала подписчика.
+func (run *impl) StdOutSubscribe(opt *SubscribeOptions) Subscription {
+	return run.subscribe("stdout", run.streamOut, opt)
+}
+
+// StdOutWriter Получатель данных потока STDOUT. Установка получателя отключает сохранение данных потока,
+// данные передаются получателю и подписчикам. Если получателем является файл, а у потока нет подписчиков,
+// данные передаются в файл системным вызовом splice, минуя память процесса. Значение nil удаляет получателя.
+func (run *impl) StdOutWriter(w io.Writer) Interface {
+	const msgWriter = "stream.writer"
 
-	return run.externalOutCh
+	run.streamOut.setWriter(w)
+	run.log(LevelDebug, msgWriter, attr("stream", "stdout"), attr("writer", fmt.Sprintf("%T", w)))
+
+	return run
 }
 
 // StdOut Данные, полученные от процесса через поток STDOUT.
-func (run *impl) StdOut() (ret []byte) { return run.bufOut.Bytes() }
+func (run *impl) StdOut() []byte { return run.streamOut.bytes() }
 
 // StdErrCh Канал с данными полученными из процесса через поток STDERR. Канал будет закрыт после завершения
-// процесса. Канал не создаётся, если функция не вызывалась.
-func (run *impl) StdErrCh() (ret <-chan []byte) {
-	const msgStdErr = "открыт внешний канал STDERR"
+// процесса. Каждый вызов функции создаёт нового подписчика, получающего все новые данные потока.
+func (run *impl) StdErrCh() <-chan []byte { return run.StdErrSubscribe(nil).Ch() }
 
-	run.debug(msgStdErr)
-	run.externalErrCh = make(chan []byte, run.chanLen)
+// StdErrSubscribe Подписка на данные потока STDERR. Подписка возможна как до, так и после запуска процесса.
+// Если настройки не указаны, подписчик получает только новые данные, а передача данных ожидает освобождения
+// буфера канала подписчика.
+func (run *impl) StdErrSubscribe(opt *SubscribeOptions) Subscription {
+	return run.subscribe("stderr", run.streamErr, opt)
+}
+
+// StdErrWriter Получатель данных потока STDERR. Установка получателя отключает сохранение данных потока,
+// данные передаются получателю и подписчикам. Если получателем является файл, а у потока нет подписчиков,
+// данные передаются в файл системным вызовом splice, минуя память процесса. Значение nil удаляет получателя.
+func (run *impl) StdErrWriter(w io.Writer) Interface {
+	const msgWriter = "stream.writer"
+
+	run.streamErr.setWriter(w)
+	run.log(LevelDebug, msgWriter, attr("stream", "stderr"), attr("writer", fmt.Sprintf("%T", w)))
 
-	return run.externalErrCh
+	return run
 }
 
 // StdErr Данные, полученные от процесса через поток STDERR.
-func (run *impl) StdErr() (ret []byte) { return run.bufErr.Bytes() }
+func (run *impl) StdErr() []byte { return run.streamErr.bytes() }
+
+// Подписка на данные потока.
+func (run *impl) subscribe(name string, s *stream, opt *SubscribeOptions) Subscription {
+	const msgSubscribe = "stream.subscribe"
+	var chanLen, bufLen int
+
+	bufLen, chanLen = run.sizeGet()
+	if opt != nil {
+		run.log(LevelDebug, msgSubscribe,
+			attr("stream", name),
+			attr("replay", opt.Replay),
+			attr("buffer", opt.Buffer),
+			attr("policy", opt.Policy),
+		)
+	} else {
+		run.log(LevelDebug, msgSubscribe, attr("stream", name))
+	}
+
+	return s.subscribe(opt, chanLen, bufLen)
+}
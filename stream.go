@@ -0,0 +1,372 @@
+package run
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Ошибка, возвращаемая, если передача данных системным вызовом splice между файлами невозможна.
+var errSpliceUnsupported = errors.New("передача данных системным вызовом splice не поддерживается")
+
+// Поток данных, получаемых от процесса: сохранение полученных данных и рассылка данных подписчикам.
+type stream struct {
+	sync     *sync.Mutex     // Контроль монопольного доступа к данным и подписчикам потока.
+	buf      *bytes.Buffer   // Данные, полученные от процесса.
+	capture  bool            // Сохранение данных, полученных от процесса.
+	writer   io.Writer       // Получатель данных потока.
+	target   *os.File        // Получатель данных потока, в который данные передаются системным вызовом splice.
+	noSplice bool            // Передача данных системным вызовом splice в получатель target невозможна.
+	subs     []*subscription // Подписчики потока.
+	closed   bool            // Поток закрыт, процесс завершился.
+	exited   chan struct{}   // Канал, закрываемый при завершении процесса.
+	canceled chan struct{}   // Канал, закрываемый при прерывании выполнения процесса через контекст.
+	ended    chan struct{}   // Канал, закрываемый при закрытии потока.
+}
+
+// Подписчик на данные потока.
+type subscription struct {
+	ch           chan []byte     // Канал подписчика.
+	policy       SubscribePolicy // Поведение при переполнении буфера канала.
+	drain        time.Duration   // Время ожидания приёма данных после завершения процесса, 0 - без ограничения.
+	exited       <-chan struct{} // Канал, закрываемый при завершении процесса.
+	canceled     <-chan struct{} // Канал, закрываемый при прерывании выполнения процесса через контекст.
+	sendSync     *sync.Mutex     // Блокировка на время передачи данных в канал подписчика.
+	closed       bool            // Канал подписчика закрыт, изменяется под блокировкой sendSync.
+	done         chan struct{}   // Канал, закрываемый при отмене подписки.
+	doneOnce     *sync.Once      // Однократное закрытие канала done.
+	dropped      atomic.Uint64   // Количество отброшенных байт.
+	disconnected atomic.Bool     // Подписчик отключён из-за переполнения буфера канала.
+}
+
+// Конструктор потока.
+func newStream() *stream {
+	return &stream{
+		sync:     new(sync.Mutex),
+		buf:      &bytes.Buffer{},
+		capture:  true,
+		exited:   make(chan struct{}),
+		canceled: make(chan struct{}),
+		ended:    make(chan struct{}),
+	}
+}
+
+// Установка получателя данных потока. Установка получателя отключает сохранение данных потока.
+// Значение nil удаляет получателя и включает сохранение данных.
+func (s *stream) setWriter(w io.Writer) {
+	var fh *os.File
+
+	s.sync.Lock()
+	s.writer, s.capture, s.target, s.noSplice = w, w == nil, nil, false
+	if fh, _ = w.(*os.File); fh != nil && spliceTargetCheck(fh) {
+		s.target = fh
+	}
+	s.sync.Unlock()
+}
+
+// Получатель данных, в который данные могут быть переданы системным вызовом splice, минуя память процесса.
+// Возвращается nil, если у потока есть подписчики, которым так же необходимо передать данные.
+func (s *stream) spliceTarget() (ret *os.File) {
+	s.sync.Lock()
+	if !s.noSplice && !s.capture && len(s.subs) == 0 {
+		ret = s.target
+	}
+	s.sync.Unlock()
+
+	return
+}
+
+// Отключение передачи данных системным вызовом splice.
+func (s *stream) spliceDisable() {
+	s.sync.Lock()
+	s.noSplice = true
+	s.sync.Unlock()
+}
+
+// Подписка на данные потока.
+// Данные, полученные до подписки, передаются подписчику до начала передачи новых данных частями размером не
+// более chunk байт. Если поток уже закрыт, канал подписчика закрывается после передачи сохранённых данных.
+func (s *stream) subscribe(opt *SubscribeOptions, chanLen int, chunk int) (ret *subscription) {
+	var (
+		replay []byte
+		closed bool
+	)
+
+	if opt == nil {
+		opt = &SubscribeOptions{Policy: PolicyBlock}
+	}
+	if opt.Buffer > 0 {
+		chanLen = opt.Buffer
+	}
+	ret = &subscription{
+		ch:       make(chan []byte, chanLen),
+		policy:   opt.Policy,
+		drain:    opt.DrainTimeout,
+		sendSync: new(sync.Mutex),
+		done:     make(chan struct{}),
+		doneOnce: new(sync.Once),
+	}
+	// Подписчик блокируется до передачи сохранённых данных, поэтому новые данные поступят в канал после них.
+	ret.sendSync.Lock()
+	s.sync.Lock()
+	ret.exited, ret.canceled = s.exited, s.canceled
+	if opt.Replay {
+		replay = append([]byte(nil), s.buf.Bytes()...)
+	}
+	if closed = s.closed; !closed {
+		s.subs = append(s.subs, ret)
+	}
+	s.sync.Unlock()
+	// При ожидании освобождения буфера канала сохранённые данные передаются в отдельной горутине.
+	if ret.policy == PolicyBlock && len(replay) > 0 {
+		go ret.replay(replay, chunk, closed)
+	} else {
+		ret.replay(replay, chunk, closed)
+	}
+
+	return
+}
+
+// Передача данных получателю и всем подписчикам потока с сохранением данных.
+// Срез data используется только на время вызова, подписчики получают одну общую копию данных.
+func (s *stream) publish(data []byte) (err error) {
+	var (
+		subs   []*subscription
+		writer io.Writer
+	)
+
+	if len(data) == 0 {
+		return
+	}
+	s.sync.Lock()
+	if s.capture {
+		_, _ = s.buf.Write(data)
+	}
+	subs, writer = s.subs, s.writer
+	s.sync.Unlock()
+	if writer != nil {
+		_, err = writer.Write(data)
+	}
+	if len(subs) == 0 {
+		return
+	}
+	data = append([]byte(nil), data...)
+	for _, sub := range subs {
+		sub.send(data)
+	}
+	// Удаление отписавшихся и отключённых подписчиков.
+	s.sync.Lock()
+	subs = make([]*subscription, 0, len(s.subs))
+	for _, sub := range s.subs {
+		if !sub.isDone() {
+			subs = append(subs, sub)
+		}
+	}
+	s.subs = subs
+	s.sync.Unlock()
+
+	return
+}
+
+// Копия данных, полученных от процесса.
+func (s *stream) bytes() (ret []byte) {
+	s.sync.Lock()
+	ret = append([]byte(nil), s.buf.Bytes()...)
+	s.sync.Unlock()
+
+	return
+}
+
+// Сигнал о завершении процесса: с этого момента подписчики с политикой PolicyBlock и установленным временем
+// ожидания приёма данных, не принимающие данные, отключаются по истечении этого времени.
+func (s *stream) exit() {
+	s.sync.Lock()
+	chanClose(s.exited)
+	s.sync.Unlock()
+}
+
+// Отключение подписчиков при прерывании контекста ctx до закрытия потока.
+// Подписчики с политикой PolicyBlock, не принимающие данные, отключаются сразу, поэтому чтение трубы не
+// останавливается до конца данных. Сигнал относится к текущему запуску и не затрагивает запуск после сброса.
+func (s *stream) cancelOn(ctx context.Context) {
+	var canceled, ended chan struct{}
+
+	if ctx == nil || ctx.Done() == nil {
+		return
+	}
+	s.sync.Lock()
+	canceled, ended = s.canceled, s.ended
+	s.sync.Unlock()
+	go func() {
+		select {
+		case <-ctx.Done():
+			chanClose(canceled)
+		case <-ended:
+		}
+	}()
+}
+
+// Закрытие потока после завершения процесса и закрытие каналов всех подписчиков.
+func (s *stream) close() {
+	var subs []*subscription
+
+	s.sync.Lock()
+	subs, s.subs, s.closed = s.subs, nil, true
+	chanClose(s.ended)
+	s.sync.Unlock()
+	for _, sub := range subs {
+		sub.end()
+	}
+}
+
+// Сброс потока для повторного использования: удаление данных, получателя и закрытие каналов всех подписчиков.
+func (s *stream) reset() {
+	s.close()
+	s.sync.Lock()
+	s.buf.Reset()
+	s.closed, s.capture, s.writer, s.target, s.noSplice = false, true, nil, nil, false
+	chanClose(s.exited)
+	s.exited = make(chan struct{})
+	chanClose(s.canceled)
+	s.canceled, s.ended = make(chan struct{}), make(chan struct{})
+	s.sync.Unlock()
+}
+
+// Передача сохранённых данных подписчику и снятие блокировки подписчика.
+func (sub *subscription) replay(data []byte, chunk int, end bool) {
+	var n int
+
+	defer sub.sendSync.Unlock()
+	for len(data) > 0 && !sub.isDone() {
+		if n = chunk; n <= 0 || n > len(data) {
+			n = len(data)
+		}
+		sub.deliver(data[:n])
+		data = data[n:]
+	}
+	if end {
+		sub.closeLocked()
+	}
+}
+
+// Передача данных подписчику.
+func (sub *subscription) send(data []byte) {
+	sub.sendSync.Lock()
+	sub.deliver(data)
+	sub.sendSync.Unlock()
+}
+
+// Передача данных в канал подписчика в соответствии с политикой подписки.
+// Функция вызывается под блокировкой sendSync.
+func (sub *subscription) deliver(data []byte) {
+	if sub.closed || sub.isDone() {
+		return
+	}
+	switch sub.policy {
+	case PolicyDrop:
+		select {
+		case sub.ch <- data:
+		default:
+			sub.dropped.Add(uint64(len(data)))
+		}
+	case PolicyDisconnect:
+		select {
+		case sub.ch <- data:
+		default:
+			sub.disconnect(data)
+		}
+	default:
+		select {
+		case sub.ch <- data:
+			return
+		case <-sub.done:
+			return
+		case <-sub.canceled:
+			sub.disconnect(data)
+			return
+		case <-sub.exited:
+		}
+		if sub.drain > 0 {
+			sub.drainWait(data)
+			return
+		}
+		select {
+		case sub.ch <- data:
+		case <-sub.done:
+		case <-sub.canceled:
+			sub.disconnect(data)
+		}
+	}
+}
+
+// Передача данных подписчику с политикой PolicyBlock после завершения процесса. Подписчик, не принявший
+// данные за установленное время ожидания, отключается, а данные отбрасываются.
+// Функция вызывается под блокировкой sendSync.
+func (sub *subscription) drainWait(data []byte) {
+	var timer = time.NewTimer(sub.drain)
+
+	defer timer.Stop()
+	select {
+	case sub.ch <- data:
+	case <-sub.done:
+	case <-sub.canceled:
+		sub.disconnect(data)
+	case <-timer.C:
+		sub.disconnect(data)
+	}
+}
+
+// Отключение подписчика, не принявшего данные, данные отбрасываются.
+// Функция вызывается под блокировкой sendSync.
+func (sub *subscription) disconnect(data []byte) {
+	sub.dropped.Add(uint64(len(data)))
+	sub.disconnected.Store(true)
+	sub.closeLocked()
+}
+
+// Подписка отменена.
+func (sub *subscription) isDone() bool {
+	select {
+	case <-sub.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Закрытие канала подписчика. Функция вызывается под блокировкой sendSync.
+func (sub *subscription) closeLocked() {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	sub.doneOnce.Do(func() { close(sub.done) })
+	close(sub.ch)
+}
+
+// Закрытие канала подписчика после завершения передачи данных.
+func (sub *subscription) end() {
+	sub.sendSync.Lock()
+	sub.closeLocked()
+	sub.sendSync.Unlock()
+}
+
+// Ch Канал с данными потока.
+func (sub *subscription) Ch() <-chan []byte { return sub.ch }
+
+// Dropped Количество байт, отброшенных из-за переполнения буфера канала подписчика.
+func (sub *subscription) Dropped() uint64 { return sub.dropped.Load() }
+
+// Disconnected Возвращает истину, если подписчик был отключён из-за переполнения буфера канала.
+func (sub *subscription) Disconnected() bool { return sub.disconnected.Load() }
+
+// Close Отмена подписки и закрытие канала.
+func (sub *subscription) Close() {
+	sub.doneOnce.Do(func() { close(sub.done) })
+	sub.end()
+}
@@ -0,0 +1,61 @@
+package run
+
+import "time"
+
+// SubscribePolicy Поведение потока при переполнении буфера канала подписчика.
+type SubscribePolicy int
+
+const (
+	// PolicyBlock Передача данных ожидает освобождения буфера канала подписчика, чтение потока приостанавливается.
+	// Если время ожидания DrainTimeout не указано, данные не теряются, а Wait() ожидает, пока подписчик не примет
+	// все данные или не вызовет Close(). Данные теряются, а канал подписчика закрывается, если подписчик не
+	// принимает данные в течение DrainTimeout после завершения процесса, или после прерывания выполнения процесса
+	// через контекст, переданный в Run().
+	PolicyBlock SubscribePolicy = iota
+
+	// PolicyDrop Данные, не поместившиеся в буфер канала подписчика, отбрасываются.
+	PolicyDrop
+
+	// PolicyDisconnect Подписчик отключается, а его канал закрывается, если данные не поместились в буфер канала.
+	PolicyDisconnect
+)
+
+// String Реализация интерфейса fmt.Stringer.
+func (sp SubscribePolicy) String() string {
+	switch sp {
+	case PolicyBlock:
+		return "block"
+	case PolicyDrop:
+		return "drop"
+	case PolicyDisconnect:
+		return "disconnect"
+	default:
+		return "unknown"
+	}
+}
+
+// SubscribeOptions Настройки подписки на данные потока.
+type SubscribeOptions struct {
+	Replay       bool            // Передать подписчику данные, полученные от процесса до момента подписки.
+	Buffer       int             // Размер буфера канала подписчика, если не указан, используется размер по умолчанию.
+	Policy       SubscribePolicy // Поведение потока при переполнении буфера канала подписчика.
+	DrainTimeout time.Duration   // Время ожидания приёма данных для PolicyBlock, 0 - без ограничения.
+}
+
+// Subscription Интерфейс подписки на данные потока STDOUT или STDERR.
+type Subscription interface {
+	// Ch Канал с данными потока. Канал закрывается после завершения процесса, при сбросе пакета,
+	// при отключении подписчика или после вызова функции Close().
+	Ch() <-chan []byte
+
+	// Dropped Количество байт, отброшенных из-за переполнения буфера канала подписчика.
+	Dropped() uint64
+
+	// Disconnected Возвращает истину, если подписчик был отключён из-за переполнения буфера канала, или,
+	// для политики PolicyBlock, так как не принимал данные в течение DrainTimeout после завершения процесса
+	// или после прерывания выполнения процесса через контекст.
+	Disconnected() bool
+
+	// Close Отмена подписки и закрытие канала.
+	Close()
+}
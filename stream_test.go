@@ -0,0 +1,223 @@
+package run
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+// Медленный подписчик с политикой PolicyBlock получает все данные, если время ожидания приёма данных не указано,
+// и отключается с потерей данных, если не принимает данные в течение указанного времени после завершения процесса.
+func TestSubscribeDrain(t *testing.T) {
+	const (
+		script = "echo a; sleep 0.1; echo b; sleep 0.1; echo c"
+		output = "a\nb\nc\n"
+	)
+	var tests = []struct {
+		name         string
+		drain        time.Duration
+		disconnected bool
+	}{
+		{"wait", 0, false},
+		{"timeout", 50 * time.Millisecond, true},
+	}
+
+	for _, test := range tests {
+		var (
+			run  = New()
+			sub  = run.StdOutSubscribe(&SubscribeOptions{Buffer: 1, Policy: PolicyBlock, DrainTimeout: test.drain})
+			got  = make(chan string)
+			data []byte
+		)
+
+		go func() {
+			var buf []byte
+			for data := range sub.Ch() {
+				buf = append(buf, data...)
+				time.Sleep(300 * time.Millisecond)
+			}
+			got <- string(buf)
+		}()
+		if _, err := run.RunWait(context.Background(), "sh", "-c", script); err != nil {
+			t.Fatalf("%s: ошибка выполнения процесса: %v", test.name, err)
+		}
+		data = []byte(<-got)
+		switch {
+		case sub.Disconnected() != test.disconnected:
+			t.Errorf("%s: Disconnected() = %t, ожидается %t", test.name, sub.Disconnected(), test.disconnected)
+		case !test.disconnected && string(data) != output:
+			t.Errorf("%s: получены данные %q, ожидается %q", test.name, data, output)
+		case test.disconnected && (sub.Dropped() == 0 || len(data)+int(sub.Dropped()) != len(output)):
+			t.Errorf("%s: получено %d байт, отброшено %d байт", test.name, len(data), sub.Dropped())
+		}
+	}
+}
+
+// Размер блока данных, записываемого в трубу за одну операцию.
+const benchChunk = bufLength
+
+// Передача b.N блоков данных через трубу получателю consume, который читает трубу до конца данных.
+func benchPipe(b *testing.B, consume func(r *os.File)) {
+	var (
+		data = make([]byte, benchChunk)
+		done = make(chan struct{})
+	)
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		b.Fatalf("создание трубы прервано ошибкой: %v", err)
+	}
+	go func() {
+		defer close(done)
+		consume(r)
+	}()
+	b.SetBytes(benchChunk)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		if _, err = w.Write(data); err != nil {
+			b.Fatalf("запись в трубу прервана ошибкой: %v", err)
+		}
+	}
+	_ = w.Close()
+	<-done
+	b.StopTimer()
+}
+
+// Чтение трубы функцией goReader в поток s.
+func benchReader(b *testing.B, s *stream) func(r *os.File) {
+	var run = New().(*impl)
+
+	b.Cleanup(run.pipesClose)
+	return func(r *os.File) {
+		var beg, end = make(chan struct{}), make(chan struct{})
+
+		go run.goReader(beg, end, "stdout", s, r, bufLength)
+		<-beg
+		<-end
+	}
+}
+
+// Открытие /dev/null как получателя данных. Символьное устройство исключает стоимость записи на диск,
+// поэтому результаты сравнивают только стоимость передачи данных из трубы.
+func benchDevNull(b *testing.B) *os.File {
+	fh, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		b.Fatalf("открытие %s прервано ошибкой: %v", os.DevNull, err)
+	}
+	b.Cleanup(func() { _ = fh.Close() })
+
+	return fh
+}
+
+// Исходный путь данных пакета до пула буферов, для сравнения: goReader копирует каждый прочитанный блок
+// в новый срез и передаёт его во внутренний канал, goProcessData копирует блок ещё раз, сохраняет в bufOut
+// и передаёт копию во внешний канал. Получатель consume читает внешний канал до его закрытия.
+func benchBaseline(b *testing.B, consume func(ch <-chan []byte)) {
+	var (
+		run      = New().(*impl)
+		internal = make(chan []byte, chanLength)
+		external = make(chan []byte, chanLength)
+		bufOut   = &bytes.Buffer{}
+		done     = make(chan struct{})
+	)
+
+	b.Cleanup(run.pipesClose)
+	go func() {
+		defer close(done)
+		consume(external)
+	}()
+	go func() {
+		for buf := range internal {
+			j, tmp := benchCopySlice(buf)
+			_, _ = bufOut.Write(tmp[:j])
+			external <- tmp[:j]
+		}
+		close(external)
+	}()
+	benchPipe(b, func(r *os.File) {
+		buf := make([]byte, bufLength)
+		for {
+			n, err := r.Read(buf)
+			if n > 0 {
+				j, tmp := benchCopySlice(buf[:n])
+				internal <- tmp[:j]
+			}
+			if err != nil {
+				break
+			}
+		}
+		close(internal)
+		_ = r.Close()
+		<-done
+	})
+}
+
+// Копирование среза байт в новый срез той же длины, как в исходном пути данных.
+func benchCopySlice(b []byte) (n int, ret []byte) {
+	ret = make([]byte, len(b))
+	n = copy(ret, b)
+	return
+}
+
+// Труба -> канал подписчика: буфер чтения из пула, сохранение данных и одна копия данных на всех подписчиков.
+// Время передачи сопоставимо с исходным путём, так как его определяет сохранение данных в буфер, а выигрыш
+// состоит в меньшем объёме выделяемой памяти. Передача в файл быстрее исходного пути на порядок.
+func BenchmarkPipeToChannel(b *testing.B) {
+	var (
+		s    = newStream()
+		sub  *subscription
+		done = make(chan struct{})
+	)
+
+	sub = s.subscribe(nil, chanLength, bufLength)
+	go func() {
+		defer close(done)
+		for range sub.Ch() {
+		}
+	}()
+	benchPipe(b, benchReader(b, s))
+	s.close()
+	<-done
+}
+
+// Труба -> канал: исходный путь данных с тремя копиями каждого блока, для сравнения.
+func BenchmarkPipeToChannelBaseline(b *testing.B) {
+	benchBaseline(b, func(ch <-chan []byte) {
+		for range ch {
+		}
+	})
+}
+
+// Труба -> файл: передача данных системным вызовом splice, минуя память процесса.
+func BenchmarkPipeToFileSplice(b *testing.B) {
+	var s = newStream()
+
+	s.setWriter(benchDevNull(b))
+	if s.spliceTarget() == nil {
+		b.Skip("передача данных системным вызовом splice не поддерживается")
+	}
+	benchPipe(b, benchReader(b, s))
+}
+
+// Труба -> файл: копирование через буфер чтения из пула, без системного вызова splice.
+func BenchmarkPipeToFileCopy(b *testing.B) {
+	var s = newStream()
+
+	s.setWriter(benchDevNull(b))
+	s.spliceDisable()
+	benchPipe(b, benchReader(b, s))
+}
+
+// Труба -> файл: исходный путь данных, в котором получатель записывает в файл данные из канала, для сравнения.
+func BenchmarkPipeToFileBaseline(b *testing.B) {
+	var fh = benchDevNull(b)
+
+	benchBaseline(b, func(ch <-chan []byte) {
+		for data := range ch {
+			_, _ = fh.Write(data)
+		}
+	})
+}
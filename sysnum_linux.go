@@ -0,0 +1,9 @@
+//go:build linux && !mips && !mipsle && !mips64 && !mips64le
+
+package run
+
+// Номера системных вызовов pidfd в общей таблице системных вызовов Linux.
+const (
+	sysPidfdOpen   = 434 // Номер системного вызова pidfd_open.
+	sysPidfdSignal = 424 // Номер системного вызова pidfd_send_signal.
+)
@@ -0,0 +1,9 @@
+//go:build linux && (mips64 || mips64le)
+
+package run
+
+// Номера системных вызовов pidfd для ABI n64, смещённые на 5000 относительно общей таблицы.
+const (
+	sysPidfdOpen   = 5434 // Номер системного вызова pidfd_open.
+	sysPidfdSignal = 5424 // Номер системного вызова pidfd_send_signal.
+)
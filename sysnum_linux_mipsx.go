@@ -0,0 +1,9 @@
+//go:build linux && (mips || mipsle)
+
+package run
+
+// Номера системных вызовов pidfd для ABI o32, смещённые на 4000 относительно общей таблицы.
+const (
+	sysPidfdOpen   = 4434 // Номер системного вызова pidfd_open.
+	sysPidfdSignal = 4424 // Номер системного вызова pidfd_send_signal.
+)
@@ -0,0 +1,224 @@
+package run
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+const (
+	tempDirPattern     = "run-*"  // Шаблон имени временной рабочей директории по умолчанию.
+	tempDirArtifactMax = 16 << 20 // Максимальный размер файла результата по умолчанию, 16 МиБ.
+)
+
+const (
+	errTempDirArtifact = "недопустимое имя файла результата %q, путь должен быть относительным и не выходить " +
+		"за пределы рабочей директории"
+	errTempDirChown = "смена владельца директории %q прервана ошибкой: %s"
+	errTempDirType  = "файл результата не является обычным файлом, тип файла %q"
+	errTempDirSize  = "размер файла результата превышает %d байт"
+)
+
+// TempDirOptions Настройки временной рабочей директории.
+type TempDirOptions struct {
+	Chown         bool     // Владельцем директории становится пользователь и группа, указанные в Sudo().
+	KeepOnFailure bool     // Директория сохраняется, если процесс завершился с ошибкой или ненулевым кодом.
+	Artifacts     []string // Имена файлов относительно директории, содержимое которых сохраняется в Result.Artifacts.
+	ArtifactMax   int64    // Максимальный размер файла результата в байтах, 0 - 16 МиБ.
+}
+
+// Временная рабочая директория.
+type tempDir struct {
+	pattern string         // Шаблон имени директории.
+	opt     TempDirOptions // Настройки.
+	path    string         // Директория, созданная для запущенного процесса, в файловой системе текущего процесса.
+}
+
+// TempWorkingDirectory Для каждого запуска создаётся новая рабочая директория по шаблону os.MkdirTemp(),
+// например "build-*" в os.TempDir() или "/var/tmp/build-*", пустой шаблон равен "run-*". Директория
+// удаляется после завершения процесса, перед удалением содержимое файлов opt.Artifacts сохраняется в Result().
+// Сохраняются только обычные файлы размером не более opt.ArtifactMax, остальные пропускаются с предупреждением.
+// Если установлен Chroot(), шаблон указывает путь внутри chroot и директория создаётся внутри chroot, процесс
+// получает путь относительно директории chroot, а Result.TempDir содержит путь в файловой системе текущего процесса.
+func (run *impl) TempWorkingDirectory(pattern string, opt *TempDirOptions) Interface {
+	const msgTempDir = "config.tempdir"
+	var td = &tempDir{pattern: pattern}
+
+	if td.pattern == "" {
+		td.pattern = tempDirPattern
+	}
+	if opt != nil {
+		td.opt = *opt
+		td.opt.Artifacts = append([]string{}, opt.Artifacts...)
+	}
+	if td.opt.ArtifactMax <= 0 {
+		td.opt.ArtifactMax = tempDirArtifactMax
+	}
+	for _, name := range td.opt.Artifacts {
+		if !tempDirLocal(name) {
+			run.errSet(fmt.Errorf(errTempDirArtifact, name))
+			return run
+		}
+	}
+	run.fieldSync.Lock()
+	run.tempDir = td
+	run.fieldSync.Unlock()
+	run.log(LevelDebug, msgTempDir,
+		attr("pattern", td.pattern),
+		attr("chown", td.opt.Chown),
+		attr("keep_on_failure", td.opt.KeepOnFailure),
+		attr("artifacts", td.opt.Artifacts),
+	)
+
+	return run
+}
+
+// Создание временной рабочей директории для запускаемого процесса.
+// Возвращается путь в файловой системе процесса, в chroot - относительно директории chroot.
+// Если временная рабочая директория не настроена, возвращается пустой путь.
+func (run *impl) tempDirCreate() (ret string, err error) {
+	const msgCreate = "tempdir.create"
+	var (
+		td     *tempDir
+		cred   *Credential
+		chroot string
+		host   string
+	)
+
+	run.fieldSync.RLock()
+	if td = run.tempDir; td != nil && run.attributes.Sys != nil {
+		if chroot = run.attributes.Sys.Chroot; run.attributes.Sys.Credential != nil {
+			cred = &Credential{UserID: run.attributes.Sys.Credential.Uid, GroupID: run.attributes.Sys.Credential.Gid}
+		}
+	}
+	run.fieldSync.RUnlock()
+	if td == nil {
+		return
+	}
+	parent, base := filepath.Split(td.pattern)
+	// В chroot родительская директория разрешается внутри chroot, директория по умолчанию так же находится
+	// внутри chroot.
+	if host = parent; chroot != "" {
+		if parent == "" {
+			parent = os.TempDir()
+		}
+		if host, err = chrootResolve(chroot, parent); err != nil {
+			return
+		}
+	}
+	if host, err = os.MkdirTemp(host, base); err != nil {
+		return
+	}
+	if ret = host; chroot != "" {
+		ret = filepath.Join("/", parent, filepath.Base(host))
+	}
+	if td.opt.Chown && cred != nil {
+		if err = os.Chown(host, int(cred.UserID), int(cred.GroupID)); err != nil {
+			_ = os.RemoveAll(host)
+			return "", fmt.Errorf(errTempDirChown, host, err)
+		}
+	}
+	run.fieldSync.Lock()
+	run.tempDirActive = &tempDir{pattern: td.pattern, opt: td.opt, path: host}
+	run.fieldSync.Unlock()
+	run.log(LevelDebug, msgCreate, attr("dir", host), attr("process_dir", ret))
+
+	return
+}
+
+// Завершение работы с временной рабочей директорией: сохранение файлов результата в result и удаление
+// директории. Если result равен nil, процесс не был запущен и директория удаляется без сохранения файлов.
+func (run *impl) tempDirFinish(result *Result) {
+	const (
+		msgKeep     = "tempdir.keep"
+		msgRemove   = "tempdir.remove"
+		errArtifact = "tempdir.artifact.failed"
+		errRemove   = "tempdir.remove.failed"
+	)
+	var (
+		err  error
+		td   *tempDir
+		path string
+		data []byte
+	)
+
+	run.fieldSync.Lock()
+	td, run.tempDirActive = run.tempDirActive, nil
+	run.fieldSync.Unlock()
+	if td == nil {
+		return
+	}
+	if result != nil {
+		for _, name := range td.opt.Artifacts {
+			// Символические ссылки разрешаются внутри директории, процесс не может подменить файл результата
+			// ссылкой на файл за её пределами.
+			if path, err = chrootResolve(td.path, name); err == nil {
+				data, err = tempDirArtifact(path, td.opt.ArtifactMax)
+			}
+			if err != nil {
+				run.log(LevelWarn, errArtifact, attr("dir", td.path), attr("name", name), attr("error", err))
+				continue
+			}
+			if result.Artifacts == nil {
+				result.Artifacts = make(map[string][]byte, len(td.opt.Artifacts))
+			}
+			result.Artifacts[name] = data
+		}
+		if td.opt.KeepOnFailure && (result.Err != nil || result.ExitCode != 0) {
+			result.TempDir = td.path
+			run.log(LevelInfo, msgKeep, attr("dir", td.path), attr("exit_code", result.ExitCode))
+			return
+		}
+	}
+	if err = os.RemoveAll(td.path); err != nil {
+		run.log(LevelWarn, errRemove, attr("dir", td.path), attr("error", err))
+		return
+	}
+	run.log(LevelDebug, msgRemove, attr("dir", td.path))
+}
+
+// Чтение файла результата. Файлы, созданные процессом, могут быть каналами FIFO, устройствами или файлами
+// любого размера, поэтому читаются только обычные файлы размером не более max байт, а файл открывается без
+// ожидания и без перехода по символической ссылке, так как процесс может подменить его после проверки.
+func tempDirArtifact(path string, max int64) (ret []byte, err error) {
+	var (
+		fi os.FileInfo
+		fh *os.File
+	)
+
+	if fi, err = os.Lstat(path); err != nil {
+		return
+	}
+	if !fi.Mode().IsRegular() {
+		return nil, fmt.Errorf(errTempDirType, fi.Mode().Type().String())
+	}
+	if fh, err = os.OpenFile(path, os.O_RDONLY|syscall.O_NONBLOCK|syscall.O_NOFOLLOW, 0); err != nil {
+		return
+	}
+	defer func() { _ = fh.Close() }()
+	if fi, err = fh.Stat(); err != nil {
+		return
+	}
+	if !fi.Mode().IsRegular() {
+		return nil, fmt.Errorf(errTempDirType, fi.Mode().Type().String())
+	}
+	if fi.Size() > max {
+		return nil, fmt.Errorf(errTempDirSize, max)
+	}
+	// Файл может увеличиться после проверки размера.
+	if ret, err = io.ReadAll(io.LimitReader(fh, max+1)); err == nil && int64(len(ret)) > max {
+		ret, err = nil, fmt.Errorf(errTempDirSize, max)
+	}
+
+	return
+}
+
+// Проверка, что имя файла является относительным путём, не выходящим за пределы директории.
+func tempDirLocal(name string) bool {
+	name = filepath.Clean(name)
+
+	return name != "." && name != ".." && !filepath.IsAbs(name) && !strings.HasPrefix(name, "../")
+}
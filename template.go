@@ -0,0 +1,111 @@
+package run
+
+import (
+	"context"
+	"strings"
+	"text/template"
+)
+
+// TemplateSpec Описание запуска, программа и аргументы, значения переменных окружения и рабочая директория
+// которого являются шаблонами text/template, например "{{.Input}}", и подставляются при каждом запуске.
+// Каждый аргумент подставляется отдельно, поэтому значение с пробелами или кавычками остаётся одним аргументом.
+// Обращение к отсутствующему ключу данных является ошибкой. Описание можно запускать многократно и одновременно.
+type TemplateSpec struct {
+	spec Spec                 // Описание запуска с текстами шаблонов.
+	args []*template.Template // Шаблоны программы и аргументов.
+	env  []*template.Template // Шаблоны значений переменных окружения.
+	dir  *template.Template   // Шаблон рабочей директории.
+}
+
+// NewTemplateSpec Конструктор описания запуска с шаблонами. Шаблоны разбираются при создании, ошибка разбора
+// возвращается как *SpecError с путём к полю, например "args[2]". Функции funcs доступны в шаблонах.
+func NewTemplateSpec(spec Spec, funcs template.FuncMap) (ret *TemplateSpec, err error) {
+	var (
+		key, value string
+		tpl        *template.Template
+	)
+
+	ret = &TemplateSpec{spec: spec.Clone()}
+	parse := func(path string, text string) *template.Template {
+		if err != nil {
+			return nil
+		}
+		tpl, err = template.New(path).Funcs(funcs).Option("missingkey=error").Parse(text)
+		if err != nil {
+			err = &SpecError{Path: path, Err: err}
+		}
+		return tpl
+	}
+	for n := range ret.spec.Args {
+		ret.args = append(ret.args, parse(docPathIndex("args", n), ret.spec.Args[n]))
+	}
+	for n := range ret.spec.Env {
+		key, value, _ = strings.Cut(ret.spec.Env[n], "=")
+		ret.env = append(ret.env, parse(docPathIndex("env", n), value))
+		ret.spec.Env[n] = key
+	}
+	ret.dir = parse("dir", ret.spec.Dir)
+	if err != nil {
+		ret = nil
+	}
+
+	return
+}
+
+// Render Описание запуска с подставленными данными. Ошибка подстановки возвращается как *SpecError с путём к полю.
+func (ts *TemplateSpec) Render(data any) (ret Spec, err error) {
+	var buf strings.Builder
+
+	execute := func(path string, tpl *template.Template) string {
+		if err != nil {
+			return ""
+		}
+		buf.Reset()
+		if err = tpl.Execute(&buf, data); err != nil {
+			err = &SpecError{Path: path, Err: err}
+		}
+		return buf.String()
+	}
+	ret = ts.spec.Clone()
+	for n := range ts.args {
+		ret.Args[n] = execute(docPathIndex("args", n), ts.args[n])
+	}
+	for n := range ts.env {
+		ret.Env[n] = ts.spec.Env[n] + "=" + execute(docPathIndex("env", n), ts.env[n])
+	}
+	ret.Dir = execute("dir", ts.dir)
+	if err != nil {
+		ret = Spec{}
+	}
+
+	return
+}
+
+// Start Подстановка данных и запуск процесса без ожидания его завершения, аналогично Spec.Start().
+// Ошибку подстановки или запуска можно получить через Error() возвращённого объекта, а подставленную
+// команду - через Command(). При ошибке подстановки возвращается объект в состоянии StateFailed.
+func (ts *TemplateSpec) Start(ctx context.Context, data any) (ret Interface) {
+	const opStart = "Start"
+	var (
+		spec Spec
+		err  error
+	)
+
+	if spec, err = ts.Render(data); err != nil {
+		return newFailed(opStart, err)
+	}
+
+	return spec.Start(ctx)
+}
+
+// RunWait Подстановка данных, запуск процесса и ожидание его завершения, аналогично Spec.RunWait().
+// При ошибке подстановки объект процесса не создаётся и возвращается nil.
+func (ts *TemplateSpec) RunWait(ctx context.Context, data any) (ret Interface, err error) {
+	var spec Spec
+
+	if spec, err = ts.Render(data); err != nil {
+		return
+	}
+
+	return spec.RunWait(ctx)
+}
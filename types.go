@@ -8,41 +8,62 @@ import (
 )
 
 const (
-	bufLength  = 4 * 1024
-	chanLength = 1000
+	bufLength  = 32 * 1024
+	chanLength = 64
 )
 
 // Объект сущности пакета.
 type impl struct {
-	debugMode     bool             // Режим отладки.
-	bufLen        int              // Размер буфера среза байт.
-	chanLen       int              // Размер буфера канала.
-	err           error            // Последняя возникшая ошибка препятствующая работе пакета.
-	cmd           []string         // Команда.
-	pipeInpReader *os.File         // STDIN - труба чтения, связанная с трубой записи.
-	pipeInpWriter *os.File         // STDIN - труба записи, связанная с трубой чтения.
-	pipeOutReader *os.File         // STDOUT - труба чтения, связанная с трубой записи.
-	pipeOutWriter *os.File         // STDOUT - труба записи, связанная с трубой чтения.
-	pipeErrReader *os.File         // STDERR - труба чтения, связанная с трубой записи.
-	pipeErrWriter *os.File         // STDERR - труба записи, связанная с трубой чтения.
-	attributes    *os.ProcAttr     // Атрибуты запуска.
-	context       context.Context  // Контекст.
-	processSync   *sync.Mutex      // Контроль монопольного доступа к process.
-	process       *os.Process      // Описание запущенного процесса.
-	processStatus *os.ProcessState // Статус завершения процесса.
-	processWait   *sync.WaitGroup  // Блокировка на время выполнения процесса.
-	doneInp       chan struct{}    // Канал передачи сигнала о завершении вспомогательной горутины STDIN.
-	doneOut       chan struct{}    // Канал передачи сигнала о завершении вспомогательной горутины STDOUT.
-	doneErr       chan struct{}    // Канал передачи сигнала о завершении вспомогательной горутины STDERR.
-	doneData      chan struct{}    // Канал передачи сигнала о завершении вспомогательной горутины обработки данных.
-	stdinpCh      chan []byte      // Канал STDIN.
-	stdOutCh      chan []byte      // Канал STDOUT.
-	stdErrCh      chan []byte      // Канал STDERR.
-	onNewData     chan struct{}    // Буферизированный канал для обработки событие поступления новых данных.
-	bufInp        *bytes.Buffer    // Данные отправляемые в STDIN после запуска приложения.
-	bufOut        *bytes.Buffer    // Данные полученные из потока STDOUT.
-	bufErr        *bytes.Buffer    // Данные полученные из потока STDERR.
-	externalInpCh <-chan []byte    // Канал, полученный извне, с данными для STDIN.
-	externalOutCh chan []byte      // Канал, передаваемый вовне, с данными из STDOUT.
-	externalErrCh chan []byte      // Канал, передаваемый вовне, с данными из STDERR.
+	fieldSync        *sync.RWMutex               // Контроль конкурентного доступа к полям объекта.
+	logSync          *sync.RWMutex               // Контроль конкурентного доступа к журналу.
+	logger           Logger                      // Журнал.
+	logDebugOff      bool                        // Режим отладки выключен, отладочные сообщения не передаются в журнал.
+	eventSync        *sync.Mutex                 // Контроль монопольного доступа к функциям и каналам событий.
+	hooks            []func(Event)               // Функции, вызываемые при событиях жизненного цикла процесса.
+	eventSubs        []chan Event                // Каналы событий жизненного цикла процесса.
+	hooksBeforeStart []func(*StartRequest) error // Функции, вызываемые перед запуском процесса.
+	bufLen           int                         // Размер буфера чтения и записи потоков в байтах.
+	chanLen          int                         // Размер буфера каналов.
+	err              error                       // Последняя возникшая ошибка препятствующая работе пакета.
+	cmd              []string                    // Команда.
+	pipeInpReader    *os.File                    // STDIN - труба чтения, связанная с трубой записи.
+	pipeInpWriter    *os.File                    // STDIN - труба записи, связанная с трубой чтения.
+	pipeOutReader    *os.File                    // STDOUT - труба чтения, связанная с трубой записи.
+	pipeOutWriter    *os.File                    // STDOUT - труба записи, связанная с трубой чтения.
+	pipeErrReader    *os.File                    // STDERR - труба чтения, связанная с трубой записи.
+	pipeErrWriter    *os.File                    // STDERR - труба записи, связанная с трубой чтения.
+	attributes       *os.ProcAttr                // Атрибуты запуска.
+	envClean         bool                        // Базовое окружение процесса пустое, а не окружение текущего процесса.
+	envFiles         []dotenvEntry               // Переменные окружения, загруженные из файлов .env.
+	envChanges       []func(*envTable)           // Изменения окружения процесса в порядке вызова функций.
+	shell            string                      // Командный интерпретатор RunShell().
+	shellStrict      bool                        // Строгий режим сценариев RunShell().
+	scriptMode       ScriptMode                  // Способ передачи сценария RunScript() интерпретатору.
+	scriptFile       *os.File                    // Файл сценария RunScript(), передаваемый как файловый дескриптор.
+	tempDir          *tempDir                    // Настройки временной рабочей директории.
+	tempDirActive    *tempDir                    // Временная рабочая директория запущенного процесса.
+	redirects        [3]*redirect                // Перенаправления потоков STDIN, STDOUT и STDERR в файлы.
+	extraFiles       []*os.File                  // Дополнительные файлы, передаваемые процессу.
+	sockets          []ActivationSocket          // Сокеты, передаваемые процессу по протоколу активации сокетов.
+	cgroupPath       string                      // Путь к группе cgroup v2, в которую помещается процесс.
+	schedule         *schedule                   // Настройки планирования процесса.
+	context          context.Context             // Контекст.
+	stateSync        *sync.Mutex                 // Контроль монопольного доступа к состоянию процесса.
+	state            State                       // Состояние процесса.
+	stateCh          chan struct{}               // Канал, закрываемый при каждом изменении состояния процесса.
+	processSync      *sync.Mutex                 // Блокировка на время запуска, выполнения процесса и сброса.
+	process          *os.Process                 // Описание запущенного процесса.
+	processStatus    *os.ProcessState            // Статус завершения процесса.
+	result           *Result                     // Результат выполнения последнего завершившегося процесса.
+	processWait      *sync.WaitGroup             // Блокировка на время выполнения процесса.
+	doneInp          chan struct{}               // Канал передачи сигнала о завершении вспомогательной горутины STDIN.
+	doneOut          chan struct{}               // Канал передачи сигнала о завершении вспомогательной горутины STDOUT.
+	doneErr          chan struct{}               // Канал передачи сигнала о завершении вспомогательной горутины STDERR.
+	doneData         chan struct{}               // Канал передачи сигнала о завершении вспомогательной горутины обработки данных.
+	stdinpCh         chan []byte                 // Канал STDIN.
+	onNewData        chan struct{}               // Канал передачи сигнала о поступлении новых данных в буфер STDIN.
+	bufInp           *bytes.Buffer               // Данные отправляемые в STDIN после запуска приложения.
+	streamOut        *stream                     // Данные полученные из потока STDOUT и подписчики потока.
+	streamErr        *stream                     // Данные полученные из потока STDERR и подписчики потока.
+	externalInpCh    <-chan []byte               // Канал, полученный извне, с данными для STDIN.
 }
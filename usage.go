@@ -0,0 +1,97 @@
+package run
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+	"time"
+)
+
+// Usage Использование ресурсов завершившимся процессом.
+// Значения получены системным вызовом wait4 и, на Linux, включают ресурсы, использованные потомками процесса,
+// завершение которых процесс ожидал (семантика RUSAGE_CHILDREN для дочернего процесса).
+type Usage struct {
+	UserTime                   time.Duration // Время выполнения в режиме пользователя.
+	SystemTime                 time.Duration // Время выполнения в режиме ядра.
+	MaxRSS                     uint64        // Максимальный размер резидентной памяти в байтах.
+	MinorFaults                uint64        // Количество незначительных ошибок страниц, без операций ввода-вывода.
+	MajorFaults                uint64        // Количество значительных ошибок страниц, с операциями ввода-вывода.
+	VoluntaryContextSwitches   uint64        // Количество добровольных переключений контекста.
+	InvoluntaryContextSwitches uint64        // Количество принудительных переключений контекста.
+	BlockInput                 uint64        // Количество операций блочного ввода.
+	BlockOutput                uint64        // Количество операций блочного вывода.
+}
+
+// Result Результат выполнения процесса.
+type Result struct {
+	Pid       int               // Идентификатор завершившегося процесса.
+	ExitCode  int               // Код завершения процесса, -1 если процесс был завершён сигналом.
+	State     *os.ProcessState  // Статус завершения процесса.
+	Usage     *Usage            // Использование ресурсов процессом.
+	Err       error             // Ошибка выполнения процесса.
+	Artifacts map[string][]byte // Содержимое файлов результата из временной рабочей директории.
+	TempDir   string            // Временная рабочая директория, сохранённая после неудачного завершения процесса.
+}
+
+// UsageFrom Преобразование информации об использовании ресурсов из статуса завершения процесса.
+// Если статус не содержит информации об использовании ресурсов, возвращается nil.
+func UsageFrom(state *os.ProcessState) (ret *Usage) {
+	var (
+		ru *syscall.Rusage
+		ok bool
+	)
+
+	if state == nil {
+		return
+	}
+	if ru, ok = state.SysUsage().(*syscall.Rusage); !ok || ru == nil {
+		return
+	}
+	ret = &Usage{
+		UserTime:                   time.Duration(ru.Utime.Nano()),
+		SystemTime:                 time.Duration(ru.Stime.Nano()),
+		MaxRSS:                     uint64(ru.Maxrss),
+		MinorFaults:                uint64(ru.Minflt),
+		MajorFaults:                uint64(ru.Majflt),
+		VoluntaryContextSwitches:   uint64(ru.Nvcsw),
+		InvoluntaryContextSwitches: uint64(ru.Nivcsw),
+		BlockInput:                 uint64(ru.Inblock),
+		BlockOutput:                uint64(ru.Oublock),
+	}
+	// На всех системах кроме darwin значение ru_maxrss указывается в килобайтах.
+	if runtime.GOOS != "darwin" && runtime.GOOS != "ios" {
+		ret.MaxRSS *= 1024
+	}
+
+	return
+}
+
+// Создание результата выполнения процесса.
+func newResult(pid int, state *os.ProcessState, err error) (ret *Result) {
+	ret = &Result{Pid: pid, ExitCode: -1, State: state, Usage: UsageFrom(state), Err: err}
+	if state != nil {
+		ret.ExitCode = state.ExitCode()
+	}
+
+	return
+}
+
+// Usage Использование ресурсов последним завершившимся процессом.
+// Возвращается nil, если процесс не запускался или ещё не завершился.
+func (run *impl) Usage() (ret *Usage) {
+	if result := run.Result(); result != nil {
+		ret = result.Usage
+	}
+
+	return
+}
+
+// Result Результат выполнения последнего завершившегося процесса: статус, код завершения и использование ресурсов.
+// Возвращается nil, если процесс не запускался или ещё не завершился.
+func (run *impl) Result() (ret *Result) {
+	run.fieldSync.RLock()
+	ret = run.result
+	run.fieldSync.RUnlock()
+
+	return
+}
@@ -2,224 +2,306 @@ package run
 
 import (
 	"context"
+	"errors"
 	"io"
 	"os"
+	"time"
 )
 
-// Копирование среза байт в новый срез той же длинны.
-func (run *impl) bytesCopySlice(b []byte) (n int, ret []byte) {
-	ret = make([]byte, len(b))
-	n = copy(ret, b)
-	return
-}
-
-// Функция выполняет задачу копирования данных из потока в канал.
-func (run *impl) goReader(onBegCh chan<- struct{}, onEndCh chan<- struct{}, outputCh chan<- []byte, inputFh *os.File) {
-	const errReaderClose = "закрытие канала чтения данных прервано ошибкой: %s"
+// Функция выполняет задачу копирования данных из потока получателю и подписчикам потока.
+// Если подписчик не успевает получать данные, чтение из трубы приостанавливается, а запущенный процесс
+// блокируется при записи в заполненную трубу. После завершения процесса подписчики с установленным временем
+// ожидания приёма данных, не принимающие данные, отключаются по его истечении, а после прерывания через контекст
+// отключаются все подписчики, не принимающие данные.
+// Если данные не сохраняются, у потока нет подписчиков, а получателем является файл, данные передаются
+// системным вызовом splice из трубы в файл, минуя память процесса.
+func (run *impl) goReader(
+	onBegCh chan<- struct{},
+	onEndCh chan<- struct{},
+	name string,
+	output *stream,
+	inputFh *os.File,
+	size int,
+) {
+	const (
+		msgReaderEnd   = "stream.reader.end"
+		msgSplice      = "stream.splice.disabled"
+		errReader      = "stream.reader.read.failed"
+		errWriter      = "stream.reader.write.failed"
+		errReaderClose = "stream.reader.close.failed"
+	)
 	var (
-		err error
-		buf []byte
-		n   int
+		err    error
+		werr   error
+		buf    *[]byte
+		target *os.File
+		n      int
+		total  int
 	)
 
-	buf = make([]byte, run.bufLen)
+	buf = bufferGet(size)
+	defer bufferPut(buf)
 	chanSendSignal(onBegCh)
 	for {
-		n, err = inputFh.Read(buf)
-		j, tmp := run.bytesCopySlice(buf[:n])
-		chanSendData(outputCh, tmp[:j])
-		if n == 0 && err == io.EOF {
+		if target = output.spliceTarget(); target != nil {
+			if n, err = spliceFrom(inputFh, target, size); err == errSpliceUnsupported {
+				run.log(LevelDebug, msgSplice, attr("stream", name))
+				output.spliceDisable()
+				continue
+			}
+			if total += n; err != nil {
+				break
+			}
+			continue
+		}
+		n, err = inputFh.Read(*buf)
+		total += n
+		// Ошибка записи в получатель не прерывает передачу данных подписчикам, в журнал записывается первая ошибка.
+		if e := output.publish((*buf)[:n]); e != nil && werr == nil {
+			werr = e
+			run.log(LevelWarn, errWriter, attr("stream", name), attr("error", werr))
+		}
+		if err != nil {
 			break
 		}
 	}
+	if err != io.EOF {
+		run.log(LevelWarn, errReader, attr("stream", name), attr("error", err))
+	}
+	run.log(LevelDebug, msgReaderEnd, attr("stream", name), attr("bytes", total))
 	if err = inputFh.Close(); err != nil {
-		run.debug(errReaderClose, err)
+		run.log(LevelWarn, errReaderClose, attr("stream", name), attr("error", err))
 	}
-	chanClose(outputCh)
 	chanSendSignal(onEndCh)
 }
 
 // Функция выполняет задачу копирования данных из канала в поток.
-func (run *impl) goWriter(onBegCh chan<- struct{}, onEndCh chan<- struct{}, outputFh *os.File, inputCh <-chan []byte) {
+func (run *impl) goWriter(
+	onBegCh chan<- struct{},
+	onEndCh chan<- struct{},
+	stream string,
+	outputFh *os.File,
+	inputCh <-chan []byte,
+) {
 	const (
-		errWriter = "запись данных в исходящий поток прервана ошибкой: %s"
-		errClose  = "закрытие потока записи данных прервана ошибкой: %s"
+		msgWriterEnd = "stream.writer.end"
+		errWriter    = "stream.writer.write.failed"
+		errClose     = "stream.writer.close.failed"
 	)
 	var (
-		err  error
-		buf  []byte
-		n, k int
+		err   error
+		buf   []byte
+		n     int
+		total int
 	)
 
 	chanSendSignal(onBegCh)
 	for buf = range inputCh {
-		j, tmp := run.bytesCopySlice(buf)
-		for n = 0; len(tmp[n:j]) > 0; {
-			if k, err = outputFh.Write(tmp[n:j]); err != nil {
-				run.debug(errWriter, err)
-				break
-			}
-			n += k
+		n, err = outputFh.Write(buf)
+		if total += n; err != nil {
+			run.log(LevelWarn, errWriter, attr("stream", stream), attr("error", err))
 		}
 	}
+	run.log(LevelDebug, msgWriterEnd, attr("stream", stream), attr("bytes", total))
 	if err = outputFh.Close(); err != nil {
-		run.debug(errClose, err)
+		run.log(LevelWarn, errClose, attr("stream", stream), attr("error", err))
 	}
 	chanSendSignal(onEndCh)
 }
 
 // Функция выполняет задачу ожидания завершения запущенного процесса и закрытие всех каналов и потоков данных.
-func (run *impl) goProcessWait(onBegCh chan<- struct{}, cancelFn context.CancelFunc) {
+// Блокировка processSync, установленная при запуске процесса, снимается после закрытия канала startEnd,
+// то есть после завершения функции запуска, и после завершения процесса.
+func (run *impl) goProcessWait(
+	onBegCh chan<- struct{},
+	startEnd <-chan struct{},
+	process *os.Process,
+	cancelFn context.CancelFunc,
+) {
 	const (
-		msgPidBeg    = "процесс PID: %d запушен"
-		msgPidEnd    = "процесс PID: %d завершён"
-		mcgCloseChan = "закрытие каналов обмена данными"
-		msgCloseOut  = "закрыт внешний канал STDOUT"
-		msgCloseErr  = "закрыт внешний канал STDERR"
-		msgStopBeg   = "завершение вспомогательных горутин, начато"
-		msgStopEnd   = "завершение вспомогательных горутин, окончено"
-		errCloseInp  = "закрытие трубы STDIN прервано ошибкой: %s"
-		errCloseOut  = "закрытие трубы STDOUT прервано ошибкой: %s"
-		errCloseErr  = "закрытие трубы STDERR прервано ошибкой: %s"
+		msgPidBeg    = "process.started"
+		msgPidEnd    = "process.exited"
+		mcgCloseChan = "channels.close"
+		msgCloseOut  = "stream.close"
+		msgStopBeg   = "helpers.stop.begin"
+		msgStopEnd   = "helpers.stop.end"
+		errClose     = "pipe.close.failed"
+		opWait       = "Wait"
+	)
+	var (
+		err    error
+		pid    int
+		cmd    []string
+		begin  time.Time
+		state  *os.ProcessState
+		result *Result
 	)
-	var err error
 
 	chanSendSignal(onBegCh)
-	run.processSync.Lock()
-	run.debug(msgPidBeg, run.process.Pid)
-	// Ожидание завершения запущенного процесса.
-	if run.processStatus, err = run.process.Wait(); run.err == nil && err != nil {
+	<-startEnd
+	pid, cmd, begin = process.Pid, run.Command(), time.Now()
+	run.log(LevelInfo, msgPidBeg, attr("pid", pid), attr("argv", cmd))
+	// Ожидание завершения запущенного процесса. Процесс перестаёт быть доступным через Pid() до освобождения
+	// его PID, поэтому Pid() не возвращает PID, который может быть занят другим процессом.
+	if processWaitExit(pid) == nil {
+		run.processClear()
+	}
+	state, err = process.Wait()
+	// Подписчики с установленным временем ожидания приёма данных отключаются, если не принимают данные,
+	// оставшиеся в трубах после завершения процесса.
+	run.streamOut.exit()
+	run.streamErr.exit()
+	result = newResult(pid, state, err)
+	run.tempDirFinish(result)
+	run.fieldSync.Lock()
+	if run.processStatus, run.result = state, result; run.err == nil && err != nil {
 		run.err = err
 	}
-	run.debug(msgPidEnd, run.process.Pid)
-	// Отправка сигнала завершения в горутину обработки данных.
-	cancelFn()
 	run.process = nil
+	run.fieldSync.Unlock()
+	run.log(LevelInfo, msgPidEnd,
+		attr("pid", pid),
+		attr("exit_code", result.ExitCode),
+		attr("duration", time.Since(begin)),
+		attr("error", err),
+	)
+	// Отправка сигнала завершения в горутину обработки данных и ожидание её завершения, после чего данные
+	// в канал STDIN больше не передаются.
+	cancelFn()
+	<-run.doneData
 	// Закрытие канала и файловых дескрипторов, это вызовет завершение горутин.
-	run.debug(mcgCloseChan)
+	run.log(LevelDebug, mcgCloseChan, attr("pid", pid))
 	chanClose(run.stdinpCh)
-	if err = run.pipeInpReader.Close(); err != nil { // STDIN
-		run.debug(errCloseInp, err)
-	}
-	if err = run.pipeOutWriter.Close(); err != nil { // STDOUT
-		run.debug(errCloseOut, err)
+	// Трубы перенаправленных потоков закрыты при запуске процесса.
+	if err = run.pipeInpReader.Close(); err != nil && !errors.Is(err, os.ErrClosed) { // STDIN
+		run.log(LevelWarn, errClose, attr("stream", "stdin"), attr("error", err))
 	}
-	if err = run.pipeErrWriter.Close(); err != nil { // STDERR
-		run.debug(errCloseErr, err)
+	if err = run.pipeOutWriter.Close(); err != nil && !errors.Is(err, os.ErrClosed) { // STDOUT
+		run.log(LevelWarn, errClose, attr("stream", "stdout"), attr("error", err))
 	}
-	// Закрытие каналов передаваемых вовне.
-	if run.externalOutCh != nil {
-		run.debug(msgCloseOut)
-		chanClose(run.externalOutCh)
-		run.externalOutCh = nil
-	}
-	if run.externalErrCh != nil {
-		run.debug(msgCloseErr)
-		chanClose(run.externalErrCh)
-		run.externalErrCh = nil
+	if err = run.pipeErrWriter.Close(); err != nil && !errors.Is(err, os.ErrClosed) { // STDERR
+		run.log(LevelWarn, errClose, attr("stream", "stderr"), attr("error", err))
 	}
 	// Ожидание завершения горутин.
-	run.debug(msgStopBeg)
+	run.log(LevelDebug, msgStopBeg, attr("pid", pid))
 	<-run.doneInp
+	run.fire(Event{Type: EventStdinClosed, Pid: pid, Args: cmd})
 	<-run.doneOut
 	<-run.doneErr
-	<-run.doneData
-	run.debug(msgStopEnd)
+	run.log(LevelDebug, msgStopEnd, attr("pid", pid))
+	// Закрытие каналов подписчиков, после завершения горутин чтения, передающих в них данные.
+	run.log(LevelDebug, msgCloseOut, attr("pid", pid))
+	run.streamOut.close()
+	run.streamErr.close()
+	_ = run.stateSet(opWait, StateExited)
+	run.fire(Event{Type: EventExited, Pid: pid, Args: cmd, Result: result, Err: result.Err})
 	// Снятие блокировок.
 	run.processWait.Done()
 	run.processSync.Unlock()
 }
 
 // Функция выполняет задачу:
-// 1. Передача данных между буферами;
-// 2. Передача данных между каналами;
+// 1. Передача данных из буфера STDIN в канал STDIN;
+// 2. Передача данных из внешнего канала в канал STDIN;
 // 3. Обработка события прерывания через контекст;
-func (run *impl) goProcessData(onBegCh chan<- struct{}, onEndCh chan<- struct{}, ctx context.Context) {
+// Потоки STDOUT и STDERR обрабатываются горутинами чтения, поэтому ожидание передачи данных в STDIN не
+// задерживает ни обработку вывода процесса, ни обработку прерывания через контекст.
+func (run *impl) goProcessData(
+	onBegCh chan<- struct{},
+	onEndCh chan<- struct{},
+	ctx context.Context,
+	size int,
+	stdin bool,
+) {
 	const (
-		msgProcBeg  = "поток обмена данных запущен"
-		msgProcEnd  = "поток обмена данных завершён"
-		msgCancel   = "получен сигнал прерывания через контекст"
-		msgToStdInp = "получен срез данных для передачи в STDIN"
-		msgFrStdInp = "получены данные из канала для передачи в STDIN"
+		msgProcBeg  = "data.begin"
+		msgProcEnd  = "data.end"
+		msgCancel   = "context.cancel"
+		msgToStdInp = "stdin.buffer.data"
+		msgFrStdInp = "stdin.channel.data"
+		msgInpClose = "stdin.channel.closed"
 	)
 	var (
-		err  error
-		end  bool
-		buf  []byte
-		ext  []byte
-		n, k int
+		err    error
+		end    bool
+		ok     bool
+		buf    []byte
+		ext    []byte
+		inp    <-chan []byte
+		inpEnd <-chan []byte
+		n      int
 	)
 
-	run.debug(msgProcBeg)
-	buf, ext = make([]byte, run.bufLen), make([]byte, run.bufLen)
+	// Передача данных в канал STDIN с прерыванием через контекст.
+	// Если поток STDIN перенаправлен из файла, данные отбрасываются.
+	send := func(data []byte) bool {
+		if !stdin {
+			return true
+		}
+		select {
+		case run.stdinpCh <- data:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	run.log(LevelDebug, msgProcBeg)
 	chanSendSignal(onBegCh)
 	for {
 		if end {
 			break
 		}
+		run.fieldSync.RLock()
+		if inp = run.externalInpCh; inp == inpEnd {
+			inp = nil
+		}
+		run.fieldSync.RUnlock()
 		select {
 		// Обработка сигнала завершения обработки данных после завершения работы процесса.
 		case <-ctx.Done():
-			run.debug(msgCancel)
-			if end = true; run.process != nil {
-				if err = run.Kill(); run.err == nil && err != nil {
-					run.err = err
+			run.log(LevelDebug, msgCancel, attr("error", ctx.Err()))
+			if end = true; run.processGet() != nil {
+				if err = run.Kill(); err != nil && run.Error() == nil {
+					run.errSet(err)
 				}
 			}
 			continue
 		// Событие поступление новых данных в функцию STDIN.
 		case <-run.onNewData:
-			run.debug(msgToStdInp)
+			// Данные читаются из буфера сразу в срез, передаваемый в канал, без промежуточного копирования.
 			for {
-				if run.bufInp.Len() <= 0 {
-					break
+				run.fieldSync.Lock()
+				if n = run.bufInp.Len(); n > size {
+					n = size
 				}
+				buf = make([]byte, n)
 				n, err = run.bufInp.Read(buf)
-				j, tmp := run.bytesCopySlice(buf[:n])
-				run.stdinpCh <- tmp[:j]
-				if err != nil {
+				run.fieldSync.Unlock()
+				if n <= 0 {
 					break
 				}
-			}
-		// Событие поступления новых данных в канал STDOUT.
-		case buf = <-run.stdOutCh:
-			j, tmp := run.bytesCopySlice(buf)
-			for n = 0; len(tmp[n:j]) > 0; {
-				if k, err = run.bufOut.Write(tmp[n:j]); err != nil {
+				run.log(LevelDebug, msgToStdInp, attr("stream", "stdin"), attr("bytes", n))
+				if !send(buf[:n]) || err != nil {
 					break
 				}
-				if run.externalOutCh != nil {
-					run.externalOutCh <- tmp[n:k]
-				}
-				n += k
-			}
-		// Событие поступления новых данных в канал STDERR.
-		case buf = <-run.stdErrCh:
-			j, tmp := run.bytesCopySlice(buf)
-			for n = 0; len(tmp[n:j]) > 0; {
-				if k, err = run.bufErr.Write(tmp[n:j]); err != nil {
-					break
-				}
-				if run.externalErrCh != nil {
-					run.externalErrCh <- tmp[n:k]
-				}
-				n += k
 			}
 		// Поступление новых данных для канала STDIN.
-		case ext = <-run.externalInpCh:
-			if len(ext) <= 0 {
+		case ext, ok = <-inp:
+			if !ok {
+				run.log(LevelDebug, msgInpClose, attr("stream", "stdin"))
+				inpEnd = inp
 				continue
 			}
-			run.debug(msgFrStdInp)
-			for n = 0; len(ext[n:]) > 0; {
-				j, tmp := run.bytesCopySlice(ext[n:])
-				n += j
-				run.stdinpCh <- tmp[:j]
+			if len(ext) <= 0 {
+				continue
 			}
+			// Срез передаётся в канал STDIN без копирования, владение срезом переходит к пакету.
+			run.log(LevelDebug, msgFrStdInp, attr("stream", "stdin"), attr("bytes", len(ext)))
+			_ = send(ext)
 		}
 	}
 	chanSendSignal(onEndCh)
-	run.debug(msgProcEnd)
+	run.log(LevelDebug, msgProcEnd)
 }
 
 // Закрытие канала с защитой от паники.
@@ -233,9 +315,3 @@ func chanSendSignal(c chan<- struct{}) {
 	defer func() { _ = recover() }()
 	c <- struct{}{}
 }
-
-// Отправка данных в канал с защитой от паники.
-func chanSendData(c chan<- []byte, d []byte) {
-	defer func() { _ = recover() }()
-	c <- d
-}
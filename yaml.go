@@ -0,0 +1,588 @@
+package run
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Поддерживается подмножество YAML, достаточное для описания запуска: блочные объекты и списки с отступами
+// пробелами, однострочные списки [a, b] и объекты {a: b}, строки без кавычек, в одинарных и двойных кавычках,
+// блочные строки "|" и ">" с индикаторами отступа и обработки завершающих переводов строк, числа, логические
+// значения, null и комментарии. Якоря, ссылки, теги, многострочные строки без кавычек и в кавычках и несколько
+// документов в одном файле не поддерживаются.
+
+const (
+	errYamlIndent    = "неожиданный отступ"
+	errYamlTab       = "отступ символом табуляции не допускается"
+	errYamlKey       = "ожидается поле объекта \"ключ: значение\""
+	errYamlDuplicate = "повторяющееся поле %q"
+	errYamlQuote     = "не закрыта кавычка"
+	errYamlFlow      = "не закрыта скобка"
+	errYamlFeature   = "конструкция YAML %q не поддерживается"
+)
+
+// Число в записи JSON, остальные значения без кавычек являются строками, например "0640" или "1e".
+var yamlNumber = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// Заголовок блочной строки: стиль, индикаторы отступа и обработки завершающих переводов строк в любом порядке.
+var yamlBlockHeader = regexp.MustCompile(`^([|>])(?:([1-9])?([+-])?|([+-])([1-9]))$`)
+
+// Строка документа YAML без комментария.
+type yamlLine struct {
+	num    int      // Номер строки.
+	indent int      // Отступ.
+	text   string   // Текст строки без отступа.
+	block  *docNode // Значение блочной строки, заголовком которой завершается строка, или nil.
+}
+
+// Разбор документа YAML.
+type yamlParser struct {
+	lines []yamlLine // Не пустые строки документа.
+	pos   int        // Текущая строка.
+}
+
+// Разбор документа YAML в дерево документа.
+func docParseYAML(data []byte) (ret *docNode, err error) {
+	var p = new(yamlParser)
+
+	if p.lines, err = yamlLines(data); err != nil {
+		return
+	}
+	if len(p.lines) == 0 {
+		ret = &docNode{kind: docNull}
+		return
+	}
+	if ret, err = p.node(); err == nil && p.pos < len(p.lines) {
+		ret, err = nil, p.fail(p.lines[p.pos].num, errors.New(errYamlIndent))
+	}
+
+	return
+}
+
+// Ошибка разбора с номером строки.
+func (p *yamlParser) fail(line int, err error) error { return &SpecError{Line: line, Err: err} }
+
+// Разделение документа на строки, удаление комментариев и пустых строк.
+// Строки содержимого блочной строки сохраняются в строке заголовка блочной строки.
+func yamlLines(data []byte) (ret []yamlLine, err error) {
+	var (
+		raw    = strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+		text   string
+		indent int
+		parent int
+		header []string
+		skip   int
+	)
+
+	for n := 0; n < len(raw); n++ {
+		line := strings.TrimRight(yamlComment(raw[n]), " \t\r")
+		text = strings.TrimLeft(line, " ")
+		if indent = len(line) - len(text); text == "" {
+			continue
+		}
+		if text[0] == '\t' {
+			err = &SpecError{Line: n + 1, Err: errors.New(errYamlTab)}
+			return
+		}
+		// Маркеры начала и окончания документа.
+		if indent == 0 && (text == "---" || text == "...") {
+			continue
+		}
+		ret = append(ret, yamlLine{num: n + 1, indent: indent, text: text})
+		if header, parent = yamlBlockStart(text, indent); header == nil {
+			continue
+		}
+		ret[len(ret)-1].block, skip = yamlBlock(header, parent, raw[n+1:], n+1)
+		n += skip
+	}
+
+	return
+}
+
+// Заголовок блочной строки, которым завершается строка, и отступ узла, содержащего блочную строку.
+// Содержимое блочной строки располагается с отступом больше отступа этого узла.
+func yamlBlockStart(text string, indent int) (ret []string, parent int) {
+	parent = indent - 1
+	for yamlIsItem(text) {
+		rest := strings.TrimLeft(text[1:], " ")
+		parent, indent, text = indent, indent+len(text)-len(rest), rest
+	}
+	if _, rest, ok := yamlSplitKey(text); ok {
+		parent, text = indent, rest
+	}
+	ret = yamlBlockHeader.FindStringSubmatch(text)
+
+	return
+}
+
+// Разбор содержимого блочной строки из строк документа, следующих за заголовком в строке с номером num.
+// Возвращается значение и количество строк, относящихся к блочной строке.
+func yamlBlock(header []string, parent int, lines []string, num int) (ret *docNode, count int) {
+	var (
+		indent   = -1
+		content  []string
+		chomp    = header[3] + header[4]
+		buf      strings.Builder
+		blanks   int
+		empty    int
+		prevMore bool
+	)
+
+	// Отступ содержимого задаётся индикатором отступа относительно узла, содержащего блочную строку,
+	// или определяется по первой не пустой строке содержимого.
+	if digit := header[2] + header[5]; digit != "" {
+		if indent = int(digit[0] - '0'); parent >= 0 {
+			indent += parent
+		}
+	}
+	for ; count < len(lines); count++ {
+		line := strings.TrimRight(lines[count], "\r")
+		text := strings.TrimLeft(line, " ")
+		if text == "" {
+			content = append(content, "")
+			continue
+		}
+		if indent < 0 {
+			indent = len(line) - len(text)
+		}
+		if len(line)-len(text) < indent || len(line)-len(text) <= parent {
+			break
+		}
+		content = append(content, line[indent:])
+	}
+	// Завершающие пустые строки обрабатываются в соответствии с индикатором завершающих переводов строк.
+	for len(content) > 0 && content[len(content)-1] == "" {
+		content, blanks = content[:len(content)-1], blanks+1
+	}
+	for n, line := range content {
+		more := line != "" && (line[0] == ' ' || line[0] == '\t')
+		switch {
+		case header[1] == "|":
+			if n > 0 {
+				buf.WriteByte('\n')
+			}
+		case line == "":
+			// Пустые строки свёрнутой строки учитываются при записи следующей не пустой строки.
+			empty++
+			continue
+		case n == empty:
+			buf.WriteString(strings.Repeat("\n", empty))
+		case !more && !prevMore && empty == 0:
+			buf.WriteByte(' ')
+		case !more && !prevMore:
+			buf.WriteString(strings.Repeat("\n", empty))
+		default:
+			// Переводы строк до и после строк с дополнительным отступом сохраняются.
+			buf.WriteString(strings.Repeat("\n", empty+1))
+		}
+		buf.WriteString(line)
+		prevMore, empty = more, 0
+	}
+	switch {
+	case chomp == "-":
+	case chomp == "+":
+		buf.WriteString(strings.Repeat("\n", blanks))
+		fallthrough
+	case len(content) > 0:
+		buf.WriteByte('\n')
+	}
+	ret = &docNode{kind: docString, line: num, value: buf.String()}
+
+	return
+}
+
+// Удаление комментария из строки с учётом кавычек.
+func yamlComment(line string) string {
+	var quote byte
+
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			if i == 0 || strings.IndexByte(" \t[{,:-", line[i-1]) >= 0 {
+				quote = c
+			}
+		case c == '#':
+			if i == 0 || line[i-1] == ' ' || line[i-1] == '\t' {
+				return line[:i]
+			}
+		}
+	}
+
+	return line
+}
+
+// Признак элемента блочного списка.
+func yamlIsItem(text string) bool { return text == "-" || strings.HasPrefix(text, "- ") }
+
+// Разбор узла, начинающегося с текущей строки.
+func (p *yamlParser) node() (ret *docNode, err error) {
+	var line = p.lines[p.pos]
+
+	if yamlIsItem(line.text) {
+		return p.list(line.indent)
+	}
+	if _, _, ok := yamlSplitKey(line.text); ok {
+		return p.mapping(line.indent)
+	}
+	if p.pos++; line.block != nil {
+		return line.block, nil
+	}
+
+	return yamlScalar(line.text, line.num)
+}
+
+// Значение, расположенное на следующих строках с отступом больше indent, или null.
+// Список может располагаться на том же отступе, что и поле объекта.
+func (p *yamlParser) nested(indent int, line int, item bool) (*docNode, error) {
+	if p.pos < len(p.lines) {
+		next := p.lines[p.pos]
+		if next.indent > indent || (!item && next.indent == indent && yamlIsItem(next.text)) {
+			return p.node()
+		}
+	}
+	return &docNode{kind: docNull, line: line}, nil
+}
+
+// Разбор блочного списка.
+func (p *yamlParser) list(indent int) (ret *docNode, err error) {
+	var (
+		line yamlLine
+		rest string
+		item *docNode
+	)
+
+	ret = &docNode{kind: docList, line: p.lines[p.pos].num}
+	for p.pos < len(p.lines) && p.lines[p.pos].indent == indent && yamlIsItem(p.lines[p.pos].text) {
+		line = p.lines[p.pos]
+		if rest = strings.TrimLeft(line.text[1:], " "); rest == "" {
+			p.pos++
+			item, err = p.nested(indent, line.num, true)
+		} else {
+			// Значение элемента на той же строке разбирается как строка с отступом до начала значения,
+			// что позволяет продолжить объект элемента на следующих строках с тем же отступом.
+			p.lines[p.pos] = yamlLine{
+				num:    line.num,
+				indent: line.indent + len(line.text) - len(rest),
+				text:   rest,
+				block:  line.block,
+			}
+			item, err = p.node()
+		}
+		if err != nil {
+			return
+		}
+		ret.items = append(ret.items, item)
+	}
+
+	return
+}
+
+// Разбор блочного объекта.
+func (p *yamlParser) mapping(indent int) (ret *docNode, err error) {
+	var (
+		line      yamlLine
+		key, rest string
+		ok        bool
+		value     *docNode
+	)
+
+	ret = &docNode{kind: docMap, line: p.lines[p.pos].num}
+	for p.pos < len(p.lines) && p.lines[p.pos].indent == indent {
+		line = p.lines[p.pos]
+		if key, rest, ok = yamlSplitKey(line.text); !ok {
+			err = p.fail(line.num, errors.New(errYamlKey))
+			return
+		}
+		if ret.field(key) != nil {
+			err = p.fail(line.num, fmt.Errorf(errYamlDuplicate, key))
+			return
+		}
+		p.pos++
+		switch {
+		case rest == "":
+			value, err = p.nested(indent, line.num, false)
+		case line.block != nil:
+			value = line.block
+		default:
+			value, err = yamlScalar(rest, line.num)
+		}
+		if err != nil {
+			return
+		}
+		ret.set(key, value)
+	}
+
+	return
+}
+
+// Разделение строки на ключ и значение поля объекта.
+func yamlSplitKey(text string) (key string, rest string, ok bool) {
+	var (
+		end int
+		err error
+	)
+
+	switch {
+	case text == "" || strings.IndexByte("[{?&*!|>%@`", text[0]) >= 0 || yamlIsItem(text):
+		return
+	case text[0] == '"' || text[0] == '\'':
+		if end = yamlQuoteEnd(text); end < 0 {
+			return
+		}
+		if key, err = yamlUnquote(text[:end+1]); err != nil {
+			return
+		}
+		if rest = text[end+1:]; !strings.HasPrefix(rest, ":") || (len(rest) > 1 && rest[1] != ' ') {
+			return "", "", false
+		}
+		rest, ok = strings.TrimSpace(rest[1:]), true
+		return
+	}
+	for i := 0; i < len(text); i++ {
+		if text[i] == ':' && (i+1 == len(text) || text[i+1] == ' ') {
+			key, rest, ok = strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+1:]), true
+			return
+		}
+	}
+
+	return
+}
+
+// Позиция закрывающей кавычки строки, начинающейся с кавычки, -1 - кавычка не закрыта.
+func yamlQuoteEnd(text string) int {
+	var quote = text[0]
+
+	for i := 1; i < len(text); i++ {
+		switch {
+		case quote == '"' && text[i] == '\\':
+			i++
+		case text[i] == quote && quote == '\'' && i+1 < len(text) && text[i+1] == '\'':
+			i++
+		case text[i] == quote:
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Значение строки в кавычках.
+func yamlUnquote(text string) (string, error) {
+	if text[0] == '\'' {
+		return strings.ReplaceAll(text[1:len(text)-1], "''", "'"), nil
+	}
+	return strconv.Unquote(text)
+}
+
+// Разбор скалярного значения или однострочного списка и объекта.
+func yamlScalar(text string, line int) (ret *docNode, err error) {
+	var end int
+
+	ret = &docNode{line: line}
+	switch text[0] {
+	case '"', '\'':
+		if end = yamlQuoteEnd(text); end != len(text)-1 {
+			err = &SpecError{Line: line, Err: errors.New(errYamlQuote)}
+			return
+		}
+		ret.kind = docString
+		if ret.value, err = yamlUnquote(text); err != nil {
+			err = &SpecError{Line: line, Err: err}
+		}
+	case '[', '{':
+		ret, err = yamlFlow(text, line)
+	case '&', '*', '!', '|', '>', '%', '@', '`':
+		err = &SpecError{Line: line, Err: fmt.Errorf(errYamlFeature, text[:1])}
+	default:
+		switch text {
+		case "null", "Null", "NULL", "~":
+			ret.kind = docNull
+		case "true", "True", "TRUE", "false", "False", "FALSE":
+			ret.kind, ret.value = docBool, strings.ToLower(text)
+		default:
+			if ret.kind, ret.value = docString, text; yamlNumber.MatchString(text) {
+				ret.kind = docNumber
+			}
+		}
+	}
+
+	return
+}
+
+// Разбор однострочного списка или объекта.
+func yamlFlow(text string, line int) (ret *docNode, err error) {
+	var (
+		closing   = map[byte]byte{'[': ']', '{': '}'}[text[0]]
+		inner     string
+		parts     []string
+		key, rest string
+		ok        bool
+		value     *docNode
+	)
+
+	if text[len(text)-1] != closing {
+		err = &SpecError{Line: line, Err: errors.New(errYamlFlow)}
+		return
+	}
+	ret = &docNode{kind: docList, line: line}
+	if closing == '}' {
+		ret.kind = docMap
+	}
+	if inner = strings.TrimSpace(text[1 : len(text)-1]); inner == "" {
+		return
+	}
+	if parts, err = yamlFlowSplit(inner, line); err != nil {
+		return
+	}
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part == "" {
+			err = &SpecError{Line: line, Err: errors.New(errYamlKey)}
+			return
+		}
+		if ret.kind == docList {
+			if value, err = yamlScalar(part, line); err != nil {
+				return
+			}
+			ret.items = append(ret.items, value)
+			continue
+		}
+		if key, rest, ok = yamlSplitKey(part); !ok || rest == "" {
+			err = &SpecError{Line: line, Err: errors.New(errYamlKey)}
+			return
+		}
+		if ret.field(key) != nil {
+			err = &SpecError{Line: line, Err: fmt.Errorf(errYamlDuplicate, key)}
+			return
+		}
+		if value, err = yamlScalar(rest, line); err != nil {
+			return
+		}
+		ret.set(key, value)
+	}
+
+	return
+}
+
+// Разделение содержимого однострочного списка или объекта по запятым вне кавычек и вложенных скобок.
+func yamlFlowSplit(text string, line int) (ret []string, err error) {
+	var (
+		depth, start int
+		end          int
+	)
+
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '"', '\'':
+			if end = yamlQuoteEnd(text[i:]); end < 0 {
+				err = &SpecError{Line: line, Err: errors.New(errYamlQuote)}
+				return
+			}
+			i += end
+		case '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				ret, start = append(ret, text[start:i]), i+1
+			}
+		}
+	}
+	if depth != 0 {
+		err = &SpecError{Line: line, Err: errors.New(errYamlFlow)}
+		return
+	}
+	ret = append(ret, text[start:])
+
+	return
+}
+
+// Запись дерева документа в формате YAML.
+func (n *docNode) writeYAML(buf *bytes.Buffer) {
+	switch {
+	case n.kind == docMap && len(n.keys) > 0:
+		n.yamlMap(buf, 0, false)
+	case n.kind == docList && len(n.items) > 0:
+		n.yamlList(buf, 0, false)
+	default:
+		buf.WriteString(n.yamlScalar())
+		buf.WriteByte('\n')
+	}
+}
+
+// Запись объекта. Если inline, отступ первого поля уже записан.
+func (n *docNode) yamlMap(buf *bytes.Buffer, indent int, inline bool) {
+	for i := range n.keys {
+		if i > 0 || !inline {
+			buf.WriteString(strings.Repeat(" ", indent))
+		}
+		buf.WriteString(docStr(n.keys[i]).yamlScalar())
+		buf.WriteByte(':')
+		switch value := n.items[i]; {
+		case value.kind == docMap && len(value.keys) > 0:
+			buf.WriteByte('\n')
+			value.yamlMap(buf, indent+2, false)
+		case value.kind == docList && len(value.items) > 0:
+			buf.WriteByte('\n')
+			value.yamlList(buf, indent+2, false)
+		default:
+			buf.WriteByte(' ')
+			buf.WriteString(value.yamlScalar())
+			buf.WriteByte('\n')
+		}
+	}
+}
+
+// Запись списка. Если inline, отступ первого элемента уже записан.
+func (n *docNode) yamlList(buf *bytes.Buffer, indent int, inline bool) {
+	for i, item := range n.items {
+		if i > 0 || !inline {
+			buf.WriteString(strings.Repeat(" ", indent))
+		}
+		buf.WriteString("- ")
+		switch {
+		case item.kind == docMap && len(item.keys) > 0:
+			item.yamlMap(buf, indent+2, true)
+		case item.kind == docList && len(item.items) > 0:
+			item.yamlList(buf, indent+2, true)
+		default:
+			buf.WriteString(item.yamlScalar())
+			buf.WriteByte('\n')
+		}
+	}
+}
+
+// Запись скалярного значения, пустого списка или пустого объекта.
+// Строки записываются без кавычек, только если они будут прочитаны обратно той же строкой.
+func (n *docNode) yamlScalar() string {
+	switch n.kind {
+	case docNull:
+		return "null"
+	case docList:
+		return "[]"
+	case docMap:
+		return "{}"
+	case docString:
+		if n.value == "" ||
+			strings.ContainsAny(n.value, ":#,[]{}\"'\\\t\r\n") ||
+			strings.IndexByte("-? ", n.value[0]) >= 0 || n.value[len(n.value)-1] == ' ' {
+			return strconv.Quote(n.value)
+		}
+		if value, err := yamlScalar(n.value, 0); err != nil || value.kind != docString || value.value != n.value {
+			return strconv.Quote(n.value)
+		}
+		return n.value
+	default:
+		return n.value
+	}
+}